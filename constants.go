@@ -9,6 +9,7 @@ const (
 	annotationRelation  = "relation"
 	annotationOmitEmpty = "omitempty"
 	annotationISO8601   = "iso8601"
+	annotationSortable  = "sortable"
 	annotationSeperator = ","
 
 	iso8601TimeFormat = "2006-01-02T15:04:05Z"
@@ -35,11 +36,19 @@ const (
 	// the next page of data
 	KeyNextPage = "next"
 
+	// KeyTotalRecords is the key to the meta object whose value is the number
+	// of records of a paginated collection
+	KeyTotalRecords = "total"
+	// KeyTotalPages is the key to the meta object whose value is the number of
+	// pages of a paginated collection
+	KeyTotalPages = "pages"
+
 	// QueryParamPageNumber is a JSON API query parameter used in a page based
 	// pagination strategy in conjunction with QueryParamPageSize
 	QueryParamPageNumber = "page[number]"
 	// QueryParamPageSize is a JSON API query parameter used in a page based
-	// pagination strategy in conjunction with QueryParamPageNumber
+	// pagination strategy in conjunction with QueryParamPageNumber, or in a
+	// cursor based one
 	QueryParamPageSize = "page[size]"
 
 	// QueryParamPageOffset is a JSON API query parameter used in an offset based
@@ -52,4 +61,39 @@ const (
 	// QueryParamPageCursor is a JSON API query parameter used with a cursor-based
 	// strategy
 	QueryParamPageCursor = "page[cursor]"
+
+	// QueryParamPageAfter is a JSON API query parameter used in a cursor based
+	// pagination strategy, in conjunction with QueryParamPageSize, to request
+	// the records that follow a cursor
+	QueryParamPageAfter = "page[after]"
+	// QueryParamPageBefore is a JSON API query parameter used in a cursor based
+	// pagination strategy, in conjunction with QueryParamPageSize, to request
+	// the records that precede a cursor
+	QueryParamPageBefore = "page[before]"
+
+	// QueryParamFields is the family of JSON API query parameters used to
+	// request sparse fieldsets, e.g. fields[posts]=title,body
+	//
+	// http://jsonapi.org/format/#fetching-sparse-fieldsets
+	QueryParamFields = "fields"
+
+	// QueryParamInclude is the JSON API query parameter used to request the
+	// related resources to be included in a compound document, e.g.
+	// include=posts.comments,current_post
+	//
+	// http://jsonapi.org/format/#fetching-includes
+	QueryParamInclude = "include"
+
+	// QueryParamSort is the JSON API query parameter used to request the order
+	// of the primary data, e.g. sort=-created_at,title
+	//
+	// http://jsonapi.org/format/#fetching-sorting
+	QueryParamSort = "sort"
+
+	// QueryParamFilter is the family of JSON API query parameters used to
+	// filter the primary data, e.g. filter[status]=open or
+	// filter[created_at][gt]=1500000000
+	//
+	// http://jsonapi.org/format/#fetching-filtering
+	QueryParamFilter = "filter"
 )
@@ -0,0 +1,361 @@
+package jsonapi
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrInvalidCursor is returned when a pagination cursor was not built by
+	// EncodeCursor for the given model and attributes.
+	ErrInvalidCursor = errors.New("Invalid pagination cursor")
+	// ErrMissingCursorAttribute is returned by PaginateCursors when a
+	// resource object lacks an attribute the cursors are built from, e.g.
+	// one left out by a sparse fieldset.
+	ErrMissingCursorAttribute = errors.New("Cursor attribute is missing")
+)
+
+// EncodeCursor returns the opaque cursor of model, a pointer to a struct, in
+// a collection ordered by the given attributes then by id.  The cursor holds
+// the values of these attributes and of the id, as marshaled into the
+// resource object of model except for times, which are held to the
+// nanosecond, e.g. to be given as page[after] to request the records that
+// follow model.  An attribute that model does not have results in an error
+// wrapping ErrUnknownAttribute.
+func EncodeCursor(model interface{}, attributes ...string) (string, error) {
+	schema, err := cursorSchema(model, attributes)
+	if err != nil {
+		return "", err
+	}
+
+	node, err := cursorNode(model, schema, attributes)
+	if err != nil {
+		return "", err
+	}
+
+	modelValue := reflect.ValueOf(model).Elem()
+	for _, attribute := range attributes {
+		fieldValue := modelValue.Field(schema.attributes[attribute].index)
+
+		switch t := fieldValue.Interface().(type) {
+		case time.Time:
+			if !t.IsZero() {
+				node.Attributes[attribute] = t.UTC().Format(time.RFC3339Nano)
+			}
+		case *time.Time:
+			if t != nil {
+				node.Attributes[attribute] = t.UTC().Format(time.RFC3339Nano)
+			}
+		}
+	}
+
+	return nodeCursor(node, attributes)
+}
+
+// DecodeCursor reads a cursor built by EncodeCursor with the same attributes
+// into model, a pointer to a struct, setting those attributes and its id with
+// the same conversions as UnmarshalPayload, except that integers and times
+// are read as they were written, e.g. to query the records that follow it.  A
+// cursor that cannot be read results in an error wrapping ErrInvalidCursor.
+func DecodeCursor(cursor string, model interface{}, attributes ...string) error {
+	schema, err := cursorSchema(model, attributes)
+	if err != nil {
+		return err
+	}
+
+	values, ok := decodeCursor(cursor)
+	if !ok || len(values) != len(attributes)+1 {
+		return ErrInvalidCursor
+	}
+
+	var id string
+	if err := json.Unmarshal(values[len(attributes)], &id); err != nil {
+		return ErrInvalidCursor
+	}
+
+	modelValue := reflect.ValueOf(model).Elem()
+	node := &Node{Type: schema.typ, ID: id, Attributes: make(map[string]interface{})}
+	for i, attribute := range attributes {
+		fieldValue := modelValue.Field(schema.attributes[attribute].index)
+
+		ok, err := setCursorValue(values[i], fieldValue)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidCursor, attribute, err)
+		}
+		if ok {
+			continue
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(values[i], &value); err != nil {
+			return ErrInvalidCursor
+		}
+		node.Attributes[attribute] = value
+	}
+
+	if err := unmarshalNode(node, reflect.ValueOf(model), nil, ""); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	return nil
+}
+
+// setCursorValue sets fieldValue to value, read from a cursor, if it is a
+// time or an integer, which unmarshalNode would only read to the second or to
+// the precision of a float64, reporting whether it did.  Times read from the
+// resource objects of a payload, see PaginateCursors, are left to
+// unmarshalNode.
+func setCursorValue(value json.RawMessage, fieldValue reflect.Value) (bool, error) {
+	if string(value) == "null" {
+		return false, nil
+	}
+
+	fieldType := fieldValue.Type()
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	target := reflect.New(fieldType)
+
+	switch {
+	case fieldType == reflect.TypeOf(time.Time{}):
+		var text string
+		if err := json.Unmarshal(value, &text); err != nil {
+			return false, nil
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, text)
+		if err != nil {
+			return true, err
+		}
+		target.Elem().Set(reflect.ValueOf(t))
+	case isIntegerKind(fieldType.Kind()) && !isAttributeUnmarshaler(target):
+		if err := json.Unmarshal(value, target.Interface()); err != nil {
+			return true, err
+		}
+	default:
+		return false, nil
+	}
+
+	if fieldValue.Kind() == reflect.Ptr {
+		fieldValue.Set(target)
+	} else {
+		fieldValue.Set(target.Elem())
+	}
+
+	return true, nil
+}
+
+// isIntegerKind reports whether k is the kind of a signed or unsigned
+// integer.
+func isIntegerKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
+
+// isAttributeUnmarshaler reports whether target, a pointer, unmarshals
+// attributes itself, see unmarshalCustomAttribute.
+func isAttributeUnmarshaler(target reflect.Value) bool {
+	switch target.Interface().(type) {
+	case AttributeUnmarshaler, json.Unmarshaler, encoding.TextUnmarshaler:
+		return true
+	}
+
+	return false
+}
+
+// cursorNode marshals the id and the given attributes of model, of the given
+// schema, without traversing its relationships.
+func cursorNode(model interface{}, schema *modelSchema, attributes []string) (*Node, error) {
+	opts := newMarshalOptions([]MarshalOption{
+		WithFields(map[string][]string{schema.typ: attributes}),
+		WithInclude(),
+	})
+	included := make(map[string]*Node)
+
+	return visitModelNode(model, &included, true, opts)
+}
+
+// cursorSchema returns the schema of model, ensuring that it has the given
+// attributes.
+func cursorSchema(model interface{}, attributes []string) (*modelSchema, error) {
+	schema, err := schemaOf(reflect.TypeOf(model).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attribute := range attributes {
+		if _, ok := schema.attributes[attribute]; !ok {
+			return nil, fmt.Errorf("%w: %s is not an attribute of %s",
+				ErrUnknownAttribute, attribute, schema.typ)
+		}
+	}
+
+	return schema, nil
+}
+
+// nodeCursor returns the cursor of the resource object node.  Attributes
+// missing from it, e.g. empty ones tagged omitempty, are null.
+func nodeCursor(node *Node, attributes []string) (string, error) {
+	values := make([]interface{}, 0, len(attributes)+1)
+	for _, attribute := range attributes {
+		values = append(values, node.Attributes[attribute])
+	}
+	values = append(values, node.ID)
+
+	buf, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// decodeCursor returns the values held by cursor, as they were written so
+// that numbers keep their precision, reporting whether it could be read.
+func decodeCursor(cursor string) ([]json.RawMessage, bool) {
+	buf, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, false
+	}
+
+	var values []json.RawMessage
+	if err := json.Unmarshal(buf, &values); err != nil || len(values) == 0 {
+		return nil, false
+	}
+
+	return values, true
+}
+
+// PaginateCursors adds the first, prev and next links of a page requested
+// with CursorPagination to the top-level links of payload, whose primary data
+// holds the records of the page, in order.  The prev and next links carry the
+// cursors of the first and last records, built from the given attributes the
+// collection is ordered by, see EncodeCursor.
+//
+// more reports whether there are records past the page in the direction it
+// was requested in, i.e. after it unless it was requested with page[before],
+// which is simplest to find out by fetching one record more than the Limit of
+// the page.
+//
+// The resource objects of payload should hold the given attributes, so sparse
+// fieldsets should keep them.  A missing attribute results in an error
+// wrapping ErrMissingCursorAttribute, unless it is a time or is tagged
+// omitempty in the model registered for the type of the resource, see
+// Register, in which case it is taken to be empty.  Times are only held to
+// the second, as they are marshaled; Query.Paginate builds the cursors from
+// the models instead.  An invalid page results in an error wrapping
+// ErrInvalidPage.
+func (p *Page) PaginateCursors(payload *ManyPayload, u *url.URL, more bool,
+	attributes ...string) error {
+	if err := p.check(); err != nil {
+		return err
+	}
+
+	var first, last string
+	if len(payload.Data) > 0 {
+		var err error
+		if first, err = payloadCursor(payload.Data[0], attributes); err != nil {
+			return err
+		}
+		if last, err = payloadCursor(payload.Data[len(payload.Data)-1], attributes); err != nil {
+			return err
+		}
+	}
+
+	p.addCursorLinks(payload, u, more, first, last)
+
+	return nil
+}
+
+// payloadCursor returns the cursor of node, a resource object of a payload,
+// ensuring that it holds the given attributes, or that they were left out for
+// being empty.
+func payloadCursor(node *Node, attributes []string) (string, error) {
+	for _, attribute := range attributes {
+		if _, ok := node.Attributes[attribute]; ok {
+			continue
+		}
+
+		if modelType, ok := registeredType(node.Type); ok {
+			if schema, err := schemaOf(modelType); err == nil {
+				// Zero times are always left out
+				if field, ok := schema.attributes[attribute]; ok &&
+					(field.omitEmpty || isTimeType(field.structField.Type)) {
+					continue
+				}
+			}
+		}
+
+		return "", fmt.Errorf("%w: %s is missing from %s %s",
+			ErrMissingCursorAttribute, attribute, node.Type, node.ID)
+	}
+
+	return nodeCursor(node, attributes)
+}
+
+// addCursorLinks adds the links of PaginateCursors to payload, given the
+// cursors of the first and last records of the page, if any.
+func (p *Page) addCursorLinks(payload *ManyPayload, u *url.URL, more bool,
+	first, last string) {
+	links := Links{KeyFirstPage: p.cursorLink(u, "", "")}
+
+	backward := p.Before != ""
+	hasPrev, hasNext := p.After != "", more
+	if backward {
+		hasPrev, hasNext = more, true
+	}
+
+	if len(payload.Data) == 0 {
+		// Point back to where the empty page was requested from
+		if p.After != "" {
+			links[KeyPreviousPage] = p.cursorLink(u, QueryParamPageBefore, p.After)
+		}
+		if p.Before != "" {
+			links[KeyNextPage] = p.cursorLink(u, QueryParamPageAfter, p.Before)
+		}
+	} else {
+		if hasPrev {
+			links[KeyPreviousPage] = p.cursorLink(u, QueryParamPageBefore, first)
+		}
+		if hasNext {
+			links[KeyNextPage] = p.cursorLink(u, QueryParamPageAfter, last)
+		}
+	}
+
+	if payload.Links == nil {
+		payload.Links = &Links{}
+	}
+	for key, link := range links {
+		(*payload.Links)[key] = link
+	}
+}
+
+// cursorLink returns u with the page size of the page and, unless key is
+// empty, the given cursor query parameter.
+func (p *Page) cursorLink(u *url.URL, key, cursor string) string {
+	query := u.Query()
+
+	query.Del(QueryParamPageAfter)
+	query.Del(QueryParamPageBefore)
+	if key != "" {
+		query.Set(key, cursor)
+	}
+	query.Set(QueryParamPageSize, strconv.Itoa(p.Limit))
+
+	link := *u
+	link.RawQuery = query.Encode()
+
+	return link.String()
+}
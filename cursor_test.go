@@ -0,0 +1,262 @@
+package jsonapi
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	blog := &Blog{
+		ID:        7,
+		Title:     "Cursors",
+		CreatedAt: time.Unix(1500000000, 0),
+		ViewCount: 1000,
+	}
+
+	cursor, err := EncodeCursor(blog, "created_at", "title")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after := new(Blog)
+	if err := DecodeCursor(cursor, after, "created_at", "title"); err != nil {
+		t.Fatal(err)
+	}
+
+	if after.ID != blog.ID || after.Title != blog.Title || !after.CreatedAt.Equal(blog.CreatedAt) {
+		t.Fatalf("Was expecting %+v, got %+v", blog, after)
+	}
+	if after.ViewCount != 0 {
+		t.Fatalf("Was expecting only the cursor attributes to be set, got %+v", after)
+	}
+}
+
+func TestCursorRoundTripPrecision(t *testing.T) {
+	article := &SortableArticle{ID: 1, Published: 1<<62 + 1}
+
+	cursor, err := EncodeCursor(article, "published")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after := new(SortableArticle)
+	if err := DecodeCursor(cursor, after, "published"); err != nil {
+		t.Fatal(err)
+	}
+	if after.Published != article.Published {
+		t.Fatalf("Was expecting %d, got %d", article.Published, after.Published)
+	}
+
+	created := time.Unix(1500000000, 123456789)
+	next := created.Add(time.Millisecond)
+	for _, blog := range []*Blog{{ID: 1, CreatedAt: created}, {ID: 2}} {
+		if cursor, err = EncodeCursor(blog, "created_at"); err != nil {
+			t.Fatal(err)
+		}
+
+		after := new(Blog)
+		if err := DecodeCursor(cursor, after, "created_at"); err != nil {
+			t.Fatal(err)
+		}
+		if !after.CreatedAt.Equal(blog.CreatedAt) {
+			t.Fatalf("Was expecting %v, got %v", blog.CreatedAt, after.CreatedAt)
+		}
+	}
+
+	timestamp := &Timestamp{ID: 1, Time: created, Next: &next}
+	if cursor, err = EncodeCursor(timestamp, "timestamp", "next"); err != nil {
+		t.Fatal(err)
+	}
+
+	readBack := new(Timestamp)
+	if err := DecodeCursor(cursor, readBack, "timestamp", "next"); err != nil {
+		t.Fatal(err)
+	}
+	if !readBack.Time.Equal(created) || readBack.Next == nil || !readBack.Next.Equal(next) {
+		t.Fatalf("Was expecting %+v, got %+v", timestamp, readBack)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	cursor, err := EncodeCursor(testBlog(), "title")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		cursor     string
+		attributes []string
+		err        error
+	}{
+		{"not a cursor", []string{"title"}, ErrInvalidCursor},
+		{cursor, []string{"title", "view_count"}, ErrInvalidCursor},
+		{cursor, []string{"view_count"}, ErrInvalidCursor},
+		{cursor, []string{"subtitle"}, ErrUnknownAttribute},
+	} {
+		if err := DecodeCursor(test.cursor, new(Blog), test.attributes...); !errors.Is(err, test.err) {
+			t.Fatalf("Was expecting %v for %v, got %v", test.err, test.attributes, err)
+		}
+	}
+
+	if _, err := EncodeCursor(testBlog(), "subtitle"); !errors.Is(err, ErrUnknownAttribute) {
+		t.Fatalf("Was expecting ErrUnknownAttribute, got %v", err)
+	}
+}
+
+func TestPaginatorParseCursors(t *testing.T) {
+	paginator := &Paginator{Strategy: CursorPagination, DefaultSize: 10}
+
+	cursor, err := EncodeCursor(testBlog(), "title")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := url.Values{QueryParamPageAfter: {cursor}, QueryParamPageSize: {"5"}}
+	page, err := paginator.Parse(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.After != cursor || page.Before != "" || page.Limit != 5 {
+		t.Fatalf("Was expecting the page after %s, got %+v", cursor, page)
+	}
+
+	for _, test := range []struct {
+		query     url.Values
+		parameter string
+	}{
+		{url.Values{QueryParamPageAfter: {cursor}, QueryParamPageBefore: {cursor}}, QueryParamPageBefore},
+		{url.Values{QueryParamPageAfter: {"not a cursor"}}, QueryParamPageAfter},
+		{url.Values{QueryParamPageNumber: {"2"}}, QueryParamPageNumber},
+	} {
+		_, err := paginator.Parse(test.query)
+
+		errorObject, ok := err.(*ErrorObject)
+		if !ok || errorObject.Source.Parameter != test.parameter {
+			t.Fatalf("Was expecting a 400 for %s, got %v", test.parameter, err)
+		}
+	}
+}
+
+func TestPaginateCursors(t *testing.T) {
+	blogs := benchmarkBlogs(3)
+
+	payload, err := MarshalMany([]interface{}{blogs[1], blogs[2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, _ := EncodeCursor(blogs[1], "title")
+	last, _ := EncodeCursor(blogs[2], "title")
+	u, _ := url.Parse("/blogs?sort=title")
+
+	// Forward, from the first blog, with more blogs to come
+	page := &Page{Limit: 2, After: first, strategy: CursorPagination}
+	if err := page.PaginateCursors(payload, u, true, "title"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Links{
+		KeyFirstPage:    "/blogs?page%5Bsize%5D=2&sort=title",
+		KeyPreviousPage: "/blogs?page%5Bbefore%5D=" + first + "&page%5Bsize%5D=2&sort=title",
+		KeyNextPage:     "/blogs?page%5Bafter%5D=" + last + "&page%5Bsize%5D=2&sort=title",
+	}
+	if !reflect.DeepEqual(expected, payload.Links) {
+		t.Fatalf("Was expecting %v, got %v", expected, payload.Links)
+	}
+
+	// Backward, reaching the start of the collection
+	payload.Links = nil
+	page = &Page{Limit: 2, Before: last, strategy: CursorPagination}
+	if err := page.PaginateCursors(payload, u, false, "title"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := (*payload.Links)[KeyPreviousPage]; ok {
+		t.Fatalf("Was expecting no prev link at the start, got %v", payload.Links)
+	}
+	if e, a := (*expected)[KeyNextPage], (*payload.Links)[KeyNextPage]; e != a {
+		t.Fatalf("Was expecting the next link %v, got %v", e, a)
+	}
+
+	// An empty page links back to its cursor
+	empty := &ManyPayload{Data: []*Node{}}
+	page = &Page{Limit: 2, After: last, strategy: CursorPagination}
+	if err := page.PaginateCursors(empty, u, false, "title"); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := "/blogs?page%5Bbefore%5D="+last+"&page%5Bsize%5D=2&sort=title", (*empty.Links)[KeyPreviousPage]; e != a {
+		t.Fatalf("Was expecting the prev link %v, got %v", e, a)
+	}
+	if _, ok := (*empty.Links)[KeyNextPage]; ok {
+		t.Fatalf("Was expecting no next link, got %v", empty.Links)
+	}
+}
+
+func TestPaginateCursorsMissingAttribute(t *testing.T) {
+	blogs := benchmarkBlogs(2)
+	page := &Page{Limit: 2, strategy: CursorPagination}
+	u, _ := url.Parse("/blogs?sort=title")
+
+	// A sparse fieldset without the attribute the blogs are sorted by
+	payload, err := MarshalMany([]interface{}{blogs[0], blogs[1]},
+		WithFields(map[string][]string{"blogs": {"view_count"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := page.PaginateCursors(payload, u, true, "title"); !errors.Is(err, ErrMissingCursorAttribute) {
+		t.Fatalf("Was expecting ErrMissingCursorAttribute, got %v", err)
+	}
+
+	// An empty attribute tagged omitempty is left out, and null in the cursor
+	MustRegister(new(Book))
+
+	books := []interface{}{&Book{ID: 1, Title: "Go"}, &Book{ID: 2}}
+	if payload, err = MarshalMany(books); err != nil {
+		t.Fatal(err)
+	}
+	if err := page.PaginateCursors(payload, u, true, "title"); err != nil {
+		t.Fatal(err)
+	}
+
+	last, _ := EncodeCursor(books[1], "title")
+	if e, a := "/blogs?page%5Bafter%5D="+last+"&page%5Bsize%5D=2&sort=title", (*payload.Links)[KeyNextPage]; e != a {
+		t.Fatalf("Was expecting the next link %v, got %v", e, a)
+	}
+
+	// So is a zero time
+	MustRegister(new(Blog))
+
+	blogs = []*Blog{{ID: 1, Title: "Go"}}
+	if payload, err = MarshalMany([]interface{}{blogs[0]}); err != nil {
+		t.Fatal(err)
+	}
+	if err := page.PaginateCursors(payload, u, true, "created_at"); err != nil {
+		t.Fatal(err)
+	}
+
+	last, _ = EncodeCursor(blogs[0], "created_at")
+	if e, a := "/blogs?page%5Bafter%5D="+last+"&page%5Bsize%5D=2&sort=title", (*payload.Links)[KeyNextPage]; e != a {
+		t.Fatalf("Was expecting the next link %v, got %v", e, a)
+	}
+}
+
+func TestPaginateCursorPages(t *testing.T) {
+	payload := &ManyPayload{Data: []*Node{}}
+	u, _ := url.Parse("/blogs")
+
+	page := &Page{Limit: 2, strategy: CursorPagination}
+	if err := page.Paginate(payload, u, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*payload.Links) != 0 {
+		t.Fatalf("Was expecting no page number links, got %v", payload.Links)
+	}
+	if e, a := (&Meta{KeyTotalRecords: 5}), payload.Meta; !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting %v, got %v", e, a)
+	}
+}
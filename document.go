@@ -0,0 +1,672 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// Violation describes a way in which a document does not conform to the JSON
+// API specification, see ValidateDocument.
+type Violation struct {
+	// Pointer is a JSON Pointer [RFC6901] to the offending member of the
+	// document, e.g. "/data/attributes/type", or "" for the document itself.
+	Pointer string
+	// Detail describes the rule of the specification that is not followed.
+	Detail string
+}
+
+// Error implements the `Error` interface.
+func (v Violation) Error() string {
+	if v.Pointer == "" {
+		return v.Detail
+	}
+
+	return fmt.Sprintf("%s: %s", v.Pointer, v.Detail)
+}
+
+// ErrorObject converts the violation into a 400 Bad Request error object
+// whose source points at the offending member.
+func (v Violation) ErrorObject() *ErrorObject {
+	errorObject := &ErrorObject{
+		Status: "400",
+		Title:  "Bad Request",
+		Detail: v.Detail,
+	}
+	if v.Pointer != "" {
+		errorObject.Source = &ErrorSource{Pointer: v.Pointer}
+	}
+
+	return errorObject
+}
+
+// DocumentError is returned when marshaling with the ValidateOutput option,
+// or unmarshaling with the ValidateInput option, a document that does not
+// conform to the JSON API specification.
+type DocumentError struct {
+	// Violations lists every violation found in the document.
+	Violations []Violation
+}
+
+// Error implements the `Error` interface.
+func (e *DocumentError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Error()
+	}
+
+	return "Invalid JSON API document: " + strings.Join(messages, "; ")
+}
+
+// ErrorObjects converts the violations into error objects, see
+// Violation.ErrorObject, e.g. to be written with MarshalErrors.
+func (e *DocumentError) ErrorObjects() []*ErrorObject {
+	errorObjects := make([]*ErrorObject, len(e.Violations))
+	for i, violation := range e.Violations {
+		errorObjects[i] = violation.ErrorObject()
+	}
+
+	return errorObjects
+}
+
+// ValidateOutput returns a MarshalOption checking the documents built by the
+// Marshal functions with ValidateDocument, returning a *DocumentError rather
+// than writing a document that does not conform to the specification.
+//
+// Note that the embedded relationships of MarshalOnePayloadEmbedded are not
+// part of the specification, and that an Encoder writes its document before
+// it is complete, so it ignores this option.
+func ValidateOutput() MarshalOption {
+	return func(o *marshalOptions) {
+		o.validate = true
+	}
+}
+
+// ValidateInput returns an UnmarshalOption checking documents with
+// ValidateDocument before unmarshaling them, returning a *DocumentError for a
+// document that does not conform to the specification.  The whole document
+// is read before anything is unmarshaled, including by a Decoder.
+func ValidateInput() UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.validate = true
+	}
+}
+
+// validatePayload checks the document built from payload when the
+// ValidateOutput option is set.
+func validatePayload(payload interface{}, opts *marshalOptions) error {
+	if !opts.validate {
+		return nil
+	}
+
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	// Full linkage is not required of documents with sparse fieldsets
+	if violations := validateDocument(bytes.NewReader(buf), opts.fields != nil); violations != nil {
+		return &DocumentError{Violations: violations}
+	}
+
+	return nil
+}
+
+// validateInput reads and checks the whole document from in when the
+// ValidateInput option is set, returning a reader of the document.
+func validateInput(in io.Reader, opts *unmarshalOptions) (io.Reader, error) {
+	if !opts.validate {
+		return in, nil
+	}
+
+	buf, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := ValidateDocument(bytes.NewReader(buf)); violations != nil {
+		return nil, &DocumentError{Violations: violations}
+	}
+
+	return bytes.NewReader(buf), nil
+}
+
+// ValidateDocument reads a JSON API document from in and checks that it
+// conforms to the structure laid out by the JSON API 1.0 and 1.1
+// specifications, e.g. in contract tests:
+//
+//   - the top-level members, e.g. "data" and "errors" cannot coexist, and
+//     "included" requires "data"
+//   - the members of resource objects, resource identifier objects,
+//     relationships, links, error objects and the "jsonapi" object
+//   - the characters of member names, and the names reserved for fields, e.g.
+//     "id" and "type" cannot be attributes
+//   - full linkage: each included resource is identified by resource linkage
+//     or primary data, and appears once in the document
+//
+// Resource objects without an id are only allowed in primary data, as when a
+// client creates a resource, and may carry the "client-id" member written for
+// client-id tags.  Members of extensions, whose names hold a
+// namespace, e.g. "atomic:operations", and @-members are allowed.
+//
+// Every violation found is returned, in a stable order; nil means the
+// document is valid.
+//
+// http://jsonapi.org/format/#document-structure
+func ValidateDocument(in io.Reader) []Violation {
+	return validateDocument(in, false)
+}
+
+// validateDocument is ValidateDocument, without the full linkage check when
+// sparse is set.
+func validateDocument(in io.Reader, sparse bool) []Violation {
+	dec := json.NewDecoder(in)
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return []Violation{{Detail: fmt.Sprintf("A document MUST be valid JSON: %v", err)}}
+	}
+
+	v := &documentValidator{
+		resources: make(map[string]string),
+		linked:    make(map[string]bool),
+		sparse:    sparse,
+	}
+	v.document(doc)
+
+	return v.violations
+}
+
+// documentValidator collects the violations of a document for
+// ValidateDocument.
+type documentValidator struct {
+	violations []Violation
+	// resources maps the "type,id" of each resource object of primary data
+	// and the "included" array to its pointer
+	resources map[string]string
+	// linked holds the "type,id" of each resource identified by resource
+	// linkage or by primary data
+	linked map[string]bool
+	// included lists the "type,id" and pointer of each included resource
+	included [][2]string
+	// sparse skips the full linkage check, which sparse fieldsets waive
+	sparse bool
+}
+
+func (v *documentValidator) violation(pointer, format string, args ...interface{}) {
+	v.violations = append(v.violations, Violation{
+		Pointer: pointer,
+		Detail:  fmt.Sprintf(format, args...),
+	})
+}
+
+// object returns value as an object, recording a violation if it is not one.
+func (v *documentValidator) object(pointer string, value interface{},
+	what string) (map[string]interface{}, bool) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		v.violation(pointer, "%s MUST be an object, not %s", what, jsonKind(value))
+	}
+
+	return object, ok
+}
+
+// str records a violation if the named member of object is present and is
+// not a string.
+func (v *documentValidator) str(pointer string, object map[string]interface{}, name string) {
+	if value, ok := object[name]; ok {
+		if _, ok := value.(string); !ok {
+			v.violation(pointer+"/"+name, "The %s member MUST be a string, not %s",
+				name, jsonKind(value))
+		}
+	}
+}
+
+// members checks the names of the members of object against the members
+// allowed by the specification, and those of extensions.
+func (v *documentValidator) members(pointer string, object map[string]interface{},
+	what string, allowed ...string) {
+	for _, name := range sortedKeys(object) {
+		if contains(allowed, name) || isAtMember(name) || isExtensionMember(name) {
+			continue
+		}
+
+		v.violation(pointer+"/"+escapePointer(name), "%s MUST NOT contain the member %q",
+			what, name)
+	}
+}
+
+// names checks the names of the members of object against the member name
+// rules, e.g. for the attributes of a resource object.
+func (v *documentValidator) names(pointer string, object map[string]interface{}) {
+	for _, name := range sortedKeys(object) {
+		if !isMemberName(name) && !isAtMember(name) {
+			v.violation(pointer+"/"+escapePointer(name), "%q is not a valid member name", name)
+		}
+	}
+}
+
+func (v *documentValidator) document(doc interface{}) {
+	top, ok := v.object("", doc, "A document")
+	if !ok {
+		return
+	}
+
+	_, hasData := top["data"]
+	_, hasErrors := top["errors"]
+	_, hasMeta := top["meta"]
+	_, hasIncluded := top["included"]
+
+	if !hasData && !hasErrors && !hasMeta {
+		v.violation("", "A document MUST contain at least one of the data, errors or meta members")
+	}
+	if hasData && hasErrors {
+		v.violation("", "The data and errors members MUST NOT coexist in a document")
+	}
+	if hasIncluded && !hasData {
+		v.violation("/included", "A document without data MUST NOT contain included")
+	}
+
+	v.members("", top, "A document",
+		"data", "errors", "meta", "jsonapi", "links", "included")
+
+	if data, ok := top["data"]; ok {
+		v.primaryData(data)
+	}
+	if included, ok := top["included"]; ok {
+		v.includedResources(included)
+	}
+	if errs, ok := top["errors"]; ok {
+		v.errorObjects(errs)
+	}
+	if meta, ok := top["meta"]; ok {
+		v.meta("/meta", meta)
+	}
+	if links, ok := top["links"]; ok {
+		v.links("/links", links)
+	}
+	if jsonapi, ok := top["jsonapi"]; ok {
+		v.jsonapiObject(jsonapi)
+	}
+
+	// Full linkage
+	for _, included := range v.included {
+		if !v.sparse && !v.linked[included[0]] {
+			v.violation(included[1],
+				"An included resource MUST be identified by resource linkage or primary data")
+		}
+	}
+}
+
+func (v *documentValidator) primaryData(data interface{}) {
+	switch data := data.(type) {
+	case nil:
+	case map[string]interface{}:
+		v.resource("/data", data, true)
+	case []interface{}:
+		for i, resource := range data {
+			v.resource(fmt.Sprintf("/data/%d", i), resource, true)
+		}
+	default:
+		v.violation("/data",
+			"Primary data MUST be null, a resource object or an array of resource objects, not %s",
+			jsonKind(data))
+	}
+}
+
+func (v *documentValidator) includedResources(included interface{}) {
+	resources, ok := included.([]interface{})
+	if !ok {
+		v.violation("/included", "The included member MUST be an array, not %s",
+			jsonKind(included))
+		return
+	}
+
+	for i, resource := range resources {
+		v.resource(fmt.Sprintf("/included/%d", i), resource, false)
+	}
+}
+
+// resource checks a resource object of primary data, or of the "included"
+// array.
+func (v *documentValidator) resource(pointer string, value interface{}, primary bool) {
+	resource, ok := v.object(pointer, value, "A resource object")
+	if !ok {
+		return
+	}
+
+	// client-id is written by this package for models with a client-id tag
+	v.members(pointer, resource, "A resource object",
+		"type", "id", "lid", "client-id", "attributes", "relationships", "links", "meta")
+
+	key, hasKey := v.identity(pointer, resource, primary)
+	if hasKey {
+		if other, ok := v.resources[key]; ok {
+			v.violation(pointer, "A resource MUST NOT appear more than once, see %s", other)
+		} else {
+			v.resources[key] = pointer
+		}
+
+		if primary {
+			v.linked[key] = true
+		} else {
+			v.included = append(v.included, [2]string{key, pointer})
+		}
+	}
+
+	var attributes, relationships map[string]interface{}
+
+	if value, ok := resource["attributes"]; ok {
+		if attributes, ok = v.object(pointer+"/attributes", value, "The attributes member"); ok {
+			v.names(pointer+"/attributes", attributes)
+
+			for _, name := range []string{"id", "type"} {
+				if _, ok := attributes[name]; ok {
+					v.violation(pointer+"/attributes/"+name,
+						"An attribute MUST NOT be named %s", name)
+				}
+			}
+
+			for _, name := range sortedKeys(attributes) {
+				v.attributeValue(pointer+"/attributes/"+escapePointer(name), attributes[name])
+			}
+		}
+	}
+
+	if value, ok := resource["relationships"]; ok {
+		if relationships, ok = v.object(pointer+"/relationships", value,
+			"The relationships member"); ok {
+			v.names(pointer+"/relationships", relationships)
+
+			for _, name := range sortedKeys(relationships) {
+				member := pointer + "/relationships/" + escapePointer(name)
+
+				if name == "id" || name == "type" {
+					v.violation(member, "A relationship MUST NOT be named %s", name)
+				}
+				if _, ok := attributes[name]; ok {
+					v.violation(member, "A resource MUST NOT have an attribute and a relationship named %s",
+						name)
+				}
+
+				v.relationship(member, relationships[name])
+			}
+		}
+	}
+
+	if links, ok := resource["links"]; ok {
+		v.links(pointer+"/links", links)
+	}
+	if meta, ok := resource["meta"]; ok {
+		v.meta(pointer+"/meta", meta)
+	}
+}
+
+// attributeValue checks the objects constituting or contained in an attribute
+// value, which MUST NOT have relationships or links members.
+func (v *documentValidator) attributeValue(pointer string, value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for _, name := range sortedKeys(value) {
+			member := pointer + "/" + escapePointer(name)
+			if name == "relationships" || name == "links" {
+				v.violation(member, "An object in an attribute value MUST NOT have a %s member",
+					name)
+			}
+
+			v.attributeValue(member, value[name])
+		}
+	case []interface{}:
+		for i, element := range value {
+			v.attributeValue(fmt.Sprintf("%s/%d", pointer, i), element)
+		}
+	}
+}
+
+// identity checks the type and id members of a resource object or resource
+// identifier object, returning its "type,id" key, if it has one.  The id can
+// be missing from resources created by a client, which may carry a local id
+// (lid) instead.
+func (v *documentValidator) identity(pointer string, object map[string]interface{},
+	idOptional bool) (string, bool) {
+	typ, ok := object["type"].(string)
+	switch {
+	case object["type"] == nil:
+		v.violation(pointer, "The type member is missing")
+	case !ok:
+		v.str(pointer, object, "type")
+	case !isMemberName(typ):
+		v.violation(pointer+"/type", "%q is not a valid type", typ)
+	}
+
+	v.str(pointer, object, "id")
+	v.str(pointer, object, "lid")
+
+	id, hasID := object["id"].(string)
+	lid, hasLID := object["lid"].(string)
+
+	if !hasID && !hasLID {
+		if object["id"] == nil && !idOptional {
+			v.violation(pointer, "The id member is missing")
+		}
+		return "", false
+	}
+
+	if !ok {
+		return "", false
+	}
+	if hasID {
+		return fmt.Sprintf("%s,%s", typ, id), true
+	}
+
+	return fmt.Sprintf("%s,lid:%s", typ, lid), true
+}
+
+func (v *documentValidator) relationship(pointer string, value interface{}) {
+	relationship, ok := v.object(pointer, value, "A relationship")
+	if !ok {
+		return
+	}
+
+	v.members(pointer, relationship, "A relationship", "links", "data", "meta")
+
+	_, hasLinks := relationship["links"]
+	_, hasData := relationship["data"]
+	_, hasMeta := relationship["meta"]
+	if !hasLinks && !hasData && !hasMeta {
+		v.violation(pointer, "A relationship MUST contain at least one of the links, data or meta members")
+	}
+
+	switch data := relationship["data"].(type) {
+	case nil:
+	case map[string]interface{}:
+		v.identifier(pointer+"/data", data)
+	case []interface{}:
+		for i, identifier := range data {
+			v.identifier(fmt.Sprintf("%s/data/%d", pointer, i), identifier)
+		}
+	default:
+		v.violation(pointer+"/data",
+			"Resource linkage MUST be null, a resource identifier object or an array of them, not %s",
+			jsonKind(data))
+	}
+
+	if links, ok := relationship["links"]; ok {
+		v.links(pointer+"/links", links)
+	}
+	if meta, ok := relationship["meta"]; ok {
+		v.meta(pointer+"/meta", meta)
+	}
+}
+
+func (v *documentValidator) identifier(pointer string, value interface{}) {
+	identifier, ok := v.object(pointer, value, "A resource identifier object")
+	if !ok {
+		return
+	}
+
+	v.members(pointer, identifier, "A resource identifier object", "type", "id", "lid", "meta")
+
+	if key, ok := v.identity(pointer, identifier, false); ok {
+		v.linked[key] = true
+	}
+
+	if meta, ok := identifier["meta"]; ok {
+		v.meta(pointer+"/meta", meta)
+	}
+}
+
+func (v *documentValidator) links(pointer string, value interface{}) {
+	links, ok := v.object(pointer, value, "A links member")
+	if !ok {
+		return
+	}
+
+	v.names(pointer, links)
+
+	for _, name := range sortedKeys(links) {
+		member := pointer + "/" + escapePointer(name)
+
+		switch link := links[name].(type) {
+		case nil, string:
+		case map[string]interface{}:
+			v.members(member, link, "A link object",
+				"href", "rel", "describedby", "title", "type", "hreflang", "meta")
+
+			if _, ok := link["href"].(string); !ok {
+				v.violation(member+"/href", "A link object MUST contain an href string")
+			}
+			for _, name := range []string{"rel", "title", "type"} {
+				v.str(member, link, name)
+			}
+			if meta, ok := link["meta"]; ok {
+				v.meta(member+"/meta", meta)
+			}
+		default:
+			v.violation(member, "A link MUST be a string, a link object or null, not %s",
+				jsonKind(link))
+		}
+	}
+}
+
+func (v *documentValidator) meta(pointer string, value interface{}) {
+	if meta, ok := v.object(pointer, value, "A meta member"); ok {
+		v.names(pointer, meta)
+	}
+}
+
+func (v *documentValidator) errorObjects(value interface{}) {
+	errs, ok := value.([]interface{})
+	if !ok {
+		v.violation("/errors", "The errors member MUST be an array, not %s", jsonKind(value))
+		return
+	}
+
+	for i, value := range errs {
+		pointer := fmt.Sprintf("/errors/%d", i)
+
+		errorObject, ok := v.object(pointer, value, "An error object")
+		if !ok {
+			continue
+		}
+
+		v.members(pointer, errorObject, "An error object",
+			"id", "links", "status", "code", "title", "detail", "source", "meta")
+
+		for _, name := range []string{"id", "status", "code", "title", "detail"} {
+			v.str(pointer, errorObject, name)
+		}
+
+		if links, ok := errorObject["links"]; ok {
+			v.links(pointer+"/links", links)
+		}
+		if meta, ok := errorObject["meta"]; ok {
+			v.meta(pointer+"/meta", meta)
+		}
+		if value, ok := errorObject["source"]; ok {
+			if source, ok := v.object(pointer+"/source", value, "The source member"); ok {
+				v.members(pointer+"/source", source, "The source member",
+					"pointer", "parameter", "header")
+				for _, name := range []string{"pointer", "parameter", "header"} {
+					v.str(pointer+"/source", source, name)
+				}
+			}
+		}
+	}
+}
+
+func (v *documentValidator) jsonapiObject(value interface{}) {
+	object, ok := v.object("/jsonapi", value, "The jsonapi member")
+	if !ok {
+		return
+	}
+
+	v.members("/jsonapi", object, "The jsonapi member", "version", "ext", "profile", "meta")
+	v.str("/jsonapi", object, "version")
+
+	for _, name := range []string{"ext", "profile"} {
+		value, ok := object[name]
+		if !ok {
+			continue
+		}
+
+		uris, ok := value.([]interface{})
+		if !ok {
+			v.violation("/jsonapi/"+name, "The %s member MUST be an array of URIs", name)
+			continue
+		}
+		for i, uri := range uris {
+			if _, ok := uri.(string); !ok {
+				v.violation(fmt.Sprintf("/jsonapi/%s/%d", name, i),
+					"The %s member MUST be an array of URIs", name)
+			}
+		}
+	}
+
+	if meta, ok := object["meta"]; ok {
+		v.meta("/jsonapi/meta", meta)
+	}
+}
+
+// isMemberName reports whether name follows the member name rules: it is
+// made of letters, digits and non-ASCII characters, along with hyphens,
+// underscores and spaces that do not start or end it.
+//
+// http://jsonapi.org/format/#document-member-names
+func isMemberName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r >= 0x80:
+		case r == '-' || r == '_' || r == ' ':
+			if i == 0 || i == len(runes)-1 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// isAtMember reports whether name is that of an @-member, which
+// implementations ignore.
+func isAtMember(name string) bool {
+	return strings.HasPrefix(name, "@") && isMemberName(name[1:])
+}
+
+// isExtensionMember reports whether name is that of a member defined by an
+// extension, prefixed by its namespace, e.g. "atomic:operations".
+func isExtensionMember(name string) bool {
+	parts := strings.Split(name, ":")
+	return len(parts) == 2 && isMemberName(parts[0]) && isMemberName(parts[1])
+}
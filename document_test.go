@@ -0,0 +1,225 @@
+package jsonapi
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func violationsAt(violations []Violation) []string {
+	pointers := make([]string, len(violations))
+	for i, violation := range violations {
+		pointers[i] = violation.Pointer
+	}
+
+	return pointers
+}
+
+func TestValidateDocumentMarshaledPayloads(t *testing.T) {
+	for name, marshal := range map[string]func(out *bytes.Buffer) error{
+		"one": func(out *bytes.Buffer) error {
+			return MarshalOnePayload(out, testBlog())
+		},
+		"many": func(out *bytes.Buffer) error {
+			return MarshalManyPayload(out, benchmarkBlogs(3))
+		},
+		"relationship": func(out *bytes.Buffer) error {
+			return MarshalRelationship(out, testBlog(), "posts")
+		},
+		"errors": func(out *bytes.Buffer) error {
+			return MarshalErrors(out, []*ErrorObject{{
+				Title:  "Invalid",
+				Source: &ErrorSource{Pointer: "/data/attributes/title"},
+			}})
+		},
+	} {
+		out := bytes.NewBuffer(nil)
+		if err := marshal(out); err != nil {
+			t.Fatal(err)
+		}
+
+		if violations := ValidateDocument(out); violations != nil {
+			t.Fatalf("Was expecting the %s document to be valid, got %v", name, violations)
+		}
+	}
+}
+
+func TestValidateDocumentViolations(t *testing.T) {
+	for document, expected := range map[string][]string{
+		`[]`:                           {""},
+		`{"data": {`:                   {""},
+		`{"links": {}}`:                {""},
+		`{"data": null, "errors": []}`: {""},
+		`{"meta": {}, "included": []}`: {"/included"},
+		`{"data": null, "extra": 1, "ext:member": 1, "@context": 1}`: {"/extra"},
+		`{"data": 1}`: {"/data"},
+		`{"data": {"type": "blogs", "id": 1, "attributes": {"id": 1, "type": 2, "-title": 3}}}`: {
+			"/data/id",
+			"/data/attributes/-title",
+			"/data/attributes/id",
+			"/data/attributes/type",
+		},
+		`{"data": {"id": "1", "attributes": {"title": 1}, "relationships": {"title": {}}}}`: {
+			"/data",
+			"/data/relationships/title",
+			"/data/relationships/title",
+		},
+		`{"data": {"type": "blogs", "id": "1", "relationships": {"posts": {"data": [{"type": "posts"}, {"type": "posts", "id": "1", "attributes": {}}]}}}}`: {
+			"/data/relationships/posts/data/0",
+			"/data/relationships/posts/data/1/attributes",
+		},
+		`{"data": {"type": "blogs", "id": "1"}, "included": [{"type": "posts", "id": "1"}, {"type": "blogs", "id": "1"}, {"type": "posts"}]}`: {
+			"/included/1",
+			"/included/2",
+			"/included/0",
+		},
+		`{"data": null, "links": {"self": 1, "next": {"meta": []}, "prev": null}}`: {
+			"/links/next/href",
+			"/links/next/meta",
+			"/links/self",
+		},
+		`{"errors": [{"status": 422, "source": {"pointer": "/data", "line": 1}}, "error"], "jsonapi": {"version": "1.1", "ext": "atomic"}}`: {
+			"/errors/0/status",
+			"/errors/0/source/line",
+			"/errors/1",
+			"/jsonapi/ext",
+		},
+	} {
+		violations := ValidateDocument(strings.NewReader(document))
+		if a := violationsAt(violations); !reflect.DeepEqual(expected, a) {
+			t.Fatalf("Was expecting violations at %q for\n%s\ngot %v", expected, document, violations)
+		}
+	}
+}
+
+func TestValidateDocumentAttributeValues(t *testing.T) {
+	// Attributes may be named relationships or links
+	valid := `{"data": {"type": "blogs", "id": "1", "attributes": {"relationships": 1, "links": {"self": "x"}}}}`
+	if violations := ValidateDocument(strings.NewReader(valid)); violations != nil {
+		t.Fatalf("Was expecting the document to be valid, got %v", violations)
+	}
+
+	// but objects in attribute values may not have such members
+	invalid := `{"data": {"type": "blogs", "id": "1", "attributes": {
+		"address": {"links": {}, "city": {"relationships": {}}},
+		"tags": [{"name": "go"}, {"links": []}]
+	}}}`
+	expected := []string{
+		"/data/attributes/address/city/relationships",
+		"/data/attributes/address/links",
+		"/data/attributes/tags/1/links",
+	}
+	violations := ValidateDocument(strings.NewReader(invalid))
+	if a := violationsAt(violations); !reflect.DeepEqual(expected, a) {
+		t.Fatalf("Was expecting violations at %q, got %v", expected, violations)
+	}
+}
+
+func TestValidateDocumentFullLinkage(t *testing.T) {
+	// A post linked from another included resource, and a comment linked
+	// from nowhere
+	document := `{
+		"data": [{"type": "blogs", "id": "1", "relationships": {"current_post": {"data": {"type": "posts", "id": "1"}}}}],
+		"included": [
+			{"type": "posts", "id": "1", "relationships": {"comments": {"data": [{"type": "comments", "id": "1"}]}}},
+			{"type": "comments", "id": "1"},
+			{"type": "comments", "id": "2"}
+		]
+	}`
+
+	violations := ValidateDocument(strings.NewReader(document))
+	if e, a := []string{"/included/2"}, violationsAt(violations); !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting violations at %v, got %v", e, violations)
+	}
+}
+
+type InvalidMemberNames struct {
+	ID    int    `jsonapi:"primary,invalid-member-names"`
+	Title string `jsonapi:"attr,_title"`
+}
+
+func TestValidateOutput(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := MarshalOnePayload(out, testBlog(), ValidateOutput()); err != nil {
+		t.Fatal(err)
+	}
+	if err := MarshalOnePayload(out, &Comment{ID: 1, ClientID: "abc"}, ValidateOutput()); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	err := MarshalManyPayload(out, []*InvalidMemberNames{{ID: 1}}, ValidateOutput())
+
+	documentError, ok := err.(*DocumentError)
+	if !ok {
+		t.Fatalf("Was expecting a *DocumentError, got %v", err)
+	}
+	if e, a := []string{"/data/0/attributes/_title"}, violationsAt(documentError.Violations); !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting violations at %v, got %v", e, a)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("Was expecting nothing to be written, got %s", out.String())
+	}
+
+	// Embedded relationships are not resource identifiers
+	if err := MarshalOnePayloadEmbedded(out, testBlog(), ValidateOutput()); err == nil {
+		t.Fatal("Was expecting the embedded payload to be invalid")
+	}
+}
+
+func TestValidateInput(t *testing.T) {
+	invalid := `{"data": {"type": "blogs", "id": "1", "attributes": {"title": "Title", "type": "blogs"}}}`
+
+	err := UnmarshalPayload(strings.NewReader(invalid), new(Blog), ValidateInput())
+
+	documentError, ok := err.(*DocumentError)
+	if !ok {
+		t.Fatalf("Was expecting a *DocumentError, got %v", err)
+	}
+	errorObjects := documentError.ErrorObjects()
+	if len(errorObjects) != 1 || errorObjects[0].Status != "400" ||
+		errorObjects[0].Source.Pointer != "/data/attributes/type" {
+		t.Fatalf("Was expecting a 400 error object for the type attribute, got %v", err)
+	}
+
+	// The document is only rejected when validating
+	blog := new(Blog)
+	if err := UnmarshalPayload(strings.NewReader(invalid), blog); err != nil {
+		t.Fatal(err)
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalManyPayload(out, benchmarkBlogs(2)); err != nil {
+		t.Fatal(err)
+	}
+	payload := out.Bytes()
+
+	blogs, err := UnmarshalManyPayload(bytes.NewReader(payload), reflect.TypeOf(new(Blog)),
+		ValidateInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blogs) != 2 {
+		t.Fatalf("Was expecting 2 blogs, got %d", len(blogs))
+	}
+
+	count := 0
+	dec := NewDecoder(bytes.NewReader(payload), ValidateInput())
+	if err := dec.DecodeEach(reflect.TypeOf(new(Blog)), func(model interface{}) error {
+		count++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("Was expecting 2 blogs to be decoded, got %d", count)
+	}
+
+	dec = NewDecoder(strings.NewReader(`{"data": {}}`), ValidateInput())
+	if _, ok := dec.DecodeEach(reflect.TypeOf(new(Blog)), func(model interface{}) error {
+		return nil
+	}).(*DocumentError); !ok {
+		t.Fatal("Was expecting the Decoder to validate its document")
+	}
+}
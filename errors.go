@@ -0,0 +1,133 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MarshalErrors writes a JSON API response using the given `[]*ErrorObject`.
+//
+// For more information on JSON API error payloads, see the spec here:
+// http://jsonapi.org/format/#document-top-level
+// and here: http://jsonapi.org/format/#error-objects.
+func MarshalErrors(w io.Writer, errorObjects []*ErrorObject) error {
+	if err := json.NewEncoder(w).Encode(&ErrorsPayload{Errors: errorObjects}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ErrorsPayload is a serializer struct for representing a valid JSON API
+// errors payload. UnmarshalPayload and UnmarshalManyPayload return an
+// *ErrorsPayload as their error when they are given an errors document.
+type ErrorsPayload struct {
+	Errors []*ErrorObject `json:"errors"`
+	Meta   *Meta          `json:"meta,omitempty"`
+}
+
+// Error implements the `Error` interface, joining the messages of all of the
+// contained error objects.
+func (e *ErrorsPayload) Error() string {
+	if len(e.Errors) == 0 {
+		return "jsonapi: errors document"
+	}
+
+	messages := make([]string, len(e.Errors))
+	for i, errorObject := range e.Errors {
+		messages[i] = errorObject.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// QueryErrors is returned by ParseFilters and ParseQuery, listing a 400 Bad
+// Request error object for each invalid query parameter of a request.  Unlike
+// an *ErrorsPayload, it is not read from an errors document.
+type QueryErrors []*ErrorObject
+
+// Error implements the `Error` interface, joining the messages of all of the
+// error objects.
+func (e QueryErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, errorObject := range e {
+		messages[i] = errorObject.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// ErrorObjects returns the error objects, e.g. to be written with
+// MarshalErrors.
+func (e QueryErrors) ErrorObjects() []*ErrorObject {
+	return []*ErrorObject(e)
+}
+
+// ErrorObject is an `Error` implementation as well as an implementation of
+// the JSON API error object.
+//
+// The main idea behind this struct is that you can use it directly in your
+// code as an error type and pass it directly to `MarshalErrors` to get your
+// JSON API errors payload written.
+//
+// http://jsonapi.org/format/#error-objects
+type ErrorObject struct {
+	// ID is a unique identifier for this particular occurrence of a problem.
+	ID string `json:"id,omitempty"`
+
+	// Links contains an "about" link leading to further details about this
+	// particular occurrence of the problem.
+	Links *ErrorLinks `json:"links,omitempty"`
+
+	// Status is the HTTP status code applicable to this problem, expressed as a
+	// string value.
+	Status string `json:"status,omitempty"`
+
+	// Code is an application-specific error code, expressed as a string value.
+	Code string `json:"code,omitempty"`
+
+	// Title is a short, human-readable summary of the problem that SHOULD NOT
+	// change from occurrence to occurrence of the problem, except for purposes
+	// of localization.
+	Title string `json:"title,omitempty"`
+
+	// Detail is a human-readable explanation specific to this occurrence of the
+	// problem. Like title, this field’s value can be localized.
+	Detail string `json:"detail,omitempty"`
+
+	// Source contains references to the source of the error.
+	Source *ErrorSource `json:"source,omitempty"`
+
+	// Meta is an object containing non-standard meta-information about the
+	// error.
+	Meta *Meta `json:"meta,omitempty"`
+}
+
+// ErrorLinks is used to represent the `links` member of an error object.
+type ErrorLinks struct {
+	// About is a link that leads to further details about this particular
+	// occurrence of the problem.
+	About string `json:"about,omitempty"`
+}
+
+// ErrorSource is used to represent the `source` member of an error object.
+type ErrorSource struct {
+	// Pointer is a JSON Pointer [RFC6901] to the associated entity in the
+	// request document, e.g. "/data" or "/data/attributes/title".
+	Pointer string `json:"pointer,omitempty"`
+
+	// Parameter is a string indicating which URI query parameter caused the
+	// error.
+	Parameter string `json:"parameter,omitempty"`
+}
+
+// Error implements the `Error` interface.
+func (e *ErrorObject) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("Error: %s", e.Title)
+	}
+
+	return fmt.Sprintf("Error: %s %s", e.Title, e.Detail)
+}
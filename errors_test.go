@@ -0,0 +1,108 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestErrorObjectWritesExpectedErrorMessage(t *testing.T) {
+	err := &ErrorObject{Title: "Title test.", Detail: "Detail test."}
+	var input error = err
+
+	output := input.Error()
+
+	if output != "Error: Title test. Detail test." {
+		t.Fatal("Unexpected output.")
+	}
+}
+
+func TestMarshalErrorsWritesTheExpectedPayload(t *testing.T) {
+	var marshalErrorsTableTests = []struct {
+		Title string
+		In    []*ErrorObject
+		Out   map[string]interface{}
+	}{
+		{
+			Title: "TestFieldsAreSerializedAsNeeded",
+			In:    []*ErrorObject{{ID: "0", Title: "Test title.", Detail: "Test detail", Status: "400", Code: "E1100"}},
+			Out: map[string]interface{}{"errors": []interface{}{
+				map[string]interface{}{"id": "0", "title": "Test title.", "detail": "Test detail", "status": "400", "code": "E1100"},
+			}},
+		},
+		{
+			Title: "TestSourceLinksAndMetaFieldsAreSerializedAsNeeded",
+			In: []*ErrorObject{
+				{
+					Title:  "Test title.",
+					Detail: "Test detail",
+					Links:  &ErrorLinks{About: "https://example.com/errors/E1100"},
+					Source: &ErrorSource{Pointer: "/data/attributes/title"},
+					Meta:   &Meta{"key": "val"},
+				},
+				{
+					Title:  "Test title.",
+					Source: &ErrorSource{Parameter: "include"},
+				},
+			},
+			Out: map[string]interface{}{"errors": []interface{}{
+				map[string]interface{}{
+					"title":  "Test title.",
+					"detail": "Test detail",
+					"links":  map[string]interface{}{"about": "https://example.com/errors/E1100"},
+					"source": map[string]interface{}{"pointer": "/data/attributes/title"},
+					"meta":   map[string]interface{}{"key": "val"},
+				},
+				map[string]interface{}{
+					"title":  "Test title.",
+					"source": map[string]interface{}{"parameter": "include"},
+				},
+			}},
+		},
+	}
+	for _, testRow := range marshalErrorsTableTests {
+		buffer, output := bytes.NewBuffer(nil), map[string]interface{}{}
+		var writer = buffer
+
+		if err := MarshalErrors(writer, testRow.In); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(buffer.Bytes(), &output); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(output, testRow.Out) {
+			t.Fatalf("Expected: \n%#v \nto equal: \n%#v", output, testRow.Out)
+		}
+	}
+}
+
+func TestUnmarshalPayloadReturnsErrorsPayload(t *testing.T) {
+	in := strings.NewReader(`{"errors": [{"status": "422", "title": "Invalid Attribute", "source": {"pointer": "/data/attributes/title"}}]}`)
+	out := new(Blog)
+
+	err := UnmarshalPayload(in, out)
+
+	errorsPayload, ok := err.(*ErrorsPayload)
+	if !ok {
+		t.Fatalf("Was expecting an *ErrorsPayload, got %#v", err)
+	}
+	if len(errorsPayload.Errors) != 1 {
+		t.Fatalf("Was expecting 1 error object, got %d", len(errorsPayload.Errors))
+	}
+	if e, a := "/data/attributes/title", errorsPayload.Errors[0].Source.Pointer; e != a {
+		t.Fatalf("Was expecting the source pointer to be %q, got %q", e, a)
+	}
+}
+
+func TestUnmarshalManyPayloadReturnsErrorsPayload(t *testing.T) {
+	in := strings.NewReader(`{"errors": [{"status": "500", "title": "Internal Server Error"}]}`)
+
+	_, err := UnmarshalManyPayload(in, reflect.TypeOf(new(Blog)))
+
+	if _, ok := err.(*ErrorsPayload); !ok {
+		t.Fatalf("Was expecting an *ErrorsPayload, got %#v", err)
+	}
+}
@@ -21,7 +21,7 @@ func createBlog(w http.ResponseWriter, r *http.Request) {
 	blog := new(Blog)
 
 	if err := jsonapiRuntime.UnmarshalPayload(r.Body, blog); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, http.StatusBadRequest, err)
 		return
 	}
 
@@ -35,18 +35,38 @@ func createBlog(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// blogsPaginator reads the pages of the blogs requested by listBlogs
+var blogsPaginator = &jsonapi.Paginator{DefaultSize: 5, MaxSize: 100}
+
 func listBlogs(w http.ResponseWriter, r *http.Request) {
-	jsonapiRuntime := jsonapi.NewRuntime().Instrument("blogs.list")
-	// ...fetch your blogs, filter, offset, limit, etc...
+	query, err := jsonapi.ParseQuery(r.URL.Query(), new(Blog), blogsPaginator)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
 
-	// but, for now
-	blogs := testBlogsForList()
+	// ...fetch the page of your blogs from your database...
 
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", jsonapi.MediaType)
-	if err := jsonapiRuntime.MarshalManyPayload(w, blogs); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// but, for now, query them in memory
+	result, err := query.Apply(testBlogsForList())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	payload, err := jsonapi.MarshalMany(result.Models)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := query.Paginate(payload, r.URL, result); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
 	}
+
+	w.Header().Set("Content-Type", jsonapi.MediaType)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(payload)
 }
 
 func showBlog(w http.ResponseWriter, r *http.Request) {
@@ -56,7 +76,14 @@ func showBlog(w http.ResponseWriter, r *http.Request) {
 
 	intID, err := strconv.Atoi(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		w.Header().Set("Content-Type", jsonapi.MediaType)
+		w.WriteHeader(http.StatusBadRequest)
+		jsonapi.MarshalErrors(w, []*jsonapi.ErrorObject{{
+			Status: strconv.Itoa(http.StatusBadRequest),
+			Title:  "Invalid Query Parameter",
+			Detail: err.Error(),
+			Source: &jsonapi.ErrorSource{Parameter: "id"},
+		}})
 		return
 	}
 
@@ -72,6 +99,23 @@ func showBlog(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// writeError responds with a JSON API errors document describing err
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", jsonapi.MediaType)
+	w.WriteHeader(status)
+
+	if queryErrors, ok := err.(jsonapi.QueryErrors); ok {
+		jsonapi.MarshalErrors(w, queryErrors.ErrorObjects())
+		return
+	}
+
+	jsonapi.MarshalErrors(w, []*jsonapi.ErrorObject{{
+		Status: strconv.Itoa(status),
+		Title:  http.StatusText(status),
+		Detail: err.Error(),
+	}})
+}
+
 func main() {
 	jsonapi.Instrumentation = func(r *jsonapi.Runtime, eventType jsonapi.Event, callGUID string, dur time.Duration) {
 		metricPrefix := r.Value("instrument").(string)
@@ -95,7 +139,7 @@ func main() {
 
 	http.HandleFunc("/blogs", func(w http.ResponseWriter, r *http.Request) {
 		if !regexp.MustCompile(`application/vnd\.api\+json`).Match([]byte(r.Header.Get("Accept"))) {
-			http.Error(w, "Unsupported Media Type", http.StatusUnsupportedMediaType)
+			writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("Accept header must include %s", jsonapi.MediaType))
 			return
 		}
 
@@ -178,7 +222,7 @@ func testBlogsForList() []interface{} {
 
 func exerciseHandler() {
 	// list
-	req, _ := http.NewRequest(http.MethodGet, "/blogs", nil)
+	req, _ := http.NewRequest(http.MethodGet, "/blogs?sort=-created_at&filter[title]=Title+1&page[number]=2&page[size]=3", nil)
 
 	req.Header.Set("Accept", jsonapi.MediaType)
 
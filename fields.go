@@ -0,0 +1,102 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// WithFields returns a MarshalOption restricting the attributes and
+// relationships marshaled for each resource type to the given sparse
+// fieldsets, e.g.
+//
+//	jsonapi.MarshalOnePayload(w, blog, jsonapi.WithFields(map[string][]string{
+//		"blogs": {"title", "posts"},
+//		"posts": {"title"},
+//	}))
+//
+// Fieldsets apply to the primary data as well as to the records sideloaded
+// into "included".  Types without a fieldset are marshaled in full, and the
+// "id" and "type" of a record are always marshaled.  A relationship left out
+// of a fieldset is not traversed, so its records are not sideloaded either,
+// unless they were requested with WithInclude: they are then sideloaded while
+// the relationship is still left out of the "relationships" of its records.
+//
+// http://jsonapi.org/format/#fetching-sparse-fieldsets
+func WithFields(fields map[string][]string) MarshalOption {
+	return func(o *marshalOptions) {
+		if o.fields == nil {
+			o.fields = make(map[string]map[string]bool)
+		}
+
+		for typ, names := range fields {
+			fieldset := make(map[string]bool, len(names))
+			for _, name := range names {
+				fieldset[name] = true
+			}
+
+			o.fields[typ] = fieldset
+		}
+	}
+}
+
+// ParseFields reads the sparse fieldsets requested with "fields[TYPE]" query
+// parameters, e.g. "fields[posts]=title,body", into a map that can be given
+// to WithFields.  A fields parameter without a type results in a 400 Bad
+// Request *ErrorObject, for the first such parameter in sorted order.
+func ParseFields(query url.Values) (map[string][]string, error) {
+	var fields map[string][]string
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if key == QueryParamFields || strings.HasPrefix(key, QueryParamFields+"[") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		values := query[key]
+
+		typ, ok := bracketed(key[len(QueryParamFields):])
+		if !ok || typ == "" {
+			return nil, &ErrorObject{
+				Status: "400",
+				Title:  "Invalid Query Parameter",
+				Detail: fmt.Sprintf(
+					"%s must name a resource type, e.g. %s[posts]",
+					key,
+					QueryParamFields,
+				),
+				Source: &ErrorSource{Parameter: key},
+			}
+		}
+
+		if fields == nil {
+			fields = make(map[string][]string)
+		}
+
+		names := []string{}
+		for _, value := range values {
+			for _, name := range strings.Split(value, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					names = append(names, name)
+				}
+			}
+		}
+
+		fields[typ] = names
+	}
+
+	return fields, nil
+}
+
+// bracketed returns the contents of s if it is of the form "[contents]".
+func bracketed(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '[' || s[len(s)-1] != ']' {
+		return "", false
+	}
+
+	return s[1 : len(s)-1], true
+}
@@ -0,0 +1,161 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestWithFieldsRestrictsPrimaryData(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := MarshalOnePayload(out, testBlog(), WithFields(map[string][]string{
+		"blogs": {"title"},
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(OnePayload)
+	if err := json.NewDecoder(out).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Data.ID != "5" || resp.Data.Type != "blogs" {
+		t.Fatalf("Was expecting id and type to always be present, got %#v", resp.Data)
+	}
+	if e, a := map[string]interface{}{"title": "Title 1"}, resp.Data.Attributes; !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting attributes %v, got %v", e, a)
+	}
+	if resp.Data.Relationships != nil {
+		t.Fatalf("Was expecting the relationships to be omitted, got %v", resp.Data.Relationships)
+	}
+	if resp.Included != nil {
+		t.Fatalf("Was expecting no included records, got %d", len(resp.Included))
+	}
+}
+
+func TestWithFieldsRestrictsIncluded(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := MarshalManyPayload(out, []*Blog{testBlog()}, WithFields(map[string][]string{
+		"blogs": {"posts"},
+		"posts": {"body"},
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(ManyPayload)
+	if err := json.NewDecoder(out).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Data[0].Attributes != nil {
+		t.Fatalf("Was expecting no attributes, got %v", resp.Data[0].Attributes)
+	}
+	if _, ok := resp.Data[0].Relationships["posts"]; !ok {
+		t.Fatalf("Was expecting the posts relationship")
+	}
+	if _, ok := resp.Data[0].Relationships["current_post"]; ok {
+		t.Fatalf("Was not expecting the current_post relationship")
+	}
+
+	if len(resp.Included) != 2 {
+		t.Fatalf("Was expecting 2 included posts, got %d", len(resp.Included))
+	}
+	for _, n := range resp.Included {
+		if n.Type != "posts" {
+			t.Fatalf("Was expecting only posts to be included, got %s", n.Type)
+		}
+		if _, ok := n.Attributes["body"]; !ok || len(n.Attributes) != 1 {
+			t.Fatalf("Was expecting only the body attribute, got %v", n.Attributes)
+		}
+		if n.Relationships != nil {
+			t.Fatalf("Was expecting no relationships, got %v", n.Relationships)
+		}
+	}
+}
+
+func TestWithFieldsKeepsRequestedIncludes(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := MarshalManyPayload(out, []*Blog{testBlog()}, WithInclude("posts.comments"),
+		WithFields(map[string][]string{
+			"blogs": {"title"},
+			"posts": {"title"},
+		})); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(ManyPayload)
+	if err := json.NewDecoder(out).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Data[0].Relationships != nil {
+		t.Fatalf("Was expecting no relationships, got %v", resp.Data[0].Relationships)
+	}
+
+	counts := map[string]int{}
+	for _, n := range resp.Included {
+		counts[n.Type]++
+		if n.Type == "posts" && n.Relationships != nil {
+			t.Fatalf("Was expecting no relationships on posts, got %v", n.Relationships)
+		}
+	}
+	if counts["posts"] != 2 || counts["comments"] == 0 || len(counts) != 2 {
+		t.Fatalf("Was expecting the included posts and comments, got %v", counts)
+	}
+
+	// Sparse fieldsets waive full linkage
+	if err := MarshalManyPayload(out, []*Blog{testBlog()}, WithInclude("posts"),
+		WithFields(map[string][]string{"blogs": {"title"}}), ValidateOutput()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	query := url.Values{
+		"fields[blogs]": {"title,posts"},
+		"fields[posts]": {""},
+		"include":       {"posts"},
+	}
+
+	fields, err := ParseFields(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{
+		"blogs": {"title", "posts"},
+		"posts": {},
+	}
+	if !reflect.DeepEqual(expected, fields) {
+		t.Fatalf("Was expecting %v, got %v", expected, fields)
+	}
+}
+
+func TestParseFieldsWithoutType(t *testing.T) {
+	for _, key := range []string{"fields", "fields[]", "fields[posts"} {
+		_, err := ParseFields(url.Values{key: {"title"}})
+
+		errorObject, ok := err.(*ErrorObject)
+		if !ok {
+			t.Fatalf("Was expecting an *ErrorObject for %s, got %v", key, err)
+		}
+		if errorObject.Status != "400" || errorObject.Source.Parameter != key {
+			t.Fatalf("Unexpected error object %#v", errorObject)
+		}
+	}
+}
+
+func TestParseFieldsReportsFirstInvalidParameter(t *testing.T) {
+	query := url.Values{"fields[]": {"title"}, "fields[posts": {"title"}, "fields[posts]": {"title"}}
+
+	for i := 0; i < 10; i++ {
+		_, err := ParseFields(query)
+
+		errorObject, ok := err.(*ErrorObject)
+		if !ok || errorObject.Source.Parameter != "fields[]" {
+			t.Fatalf("Was expecting an error object for fields[], got %v", err)
+		}
+	}
+}
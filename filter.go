@@ -0,0 +1,321 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FilterOperator is the comparison requested by a filter query parameter,
+// e.g. "gt" in filter[created_at][gt]=1500000000.
+type FilterOperator string
+
+const (
+	// FilterEq matches attributes equal to the value; it is the operator of
+	// filters without one, e.g. filter[status]=open.
+	FilterEq FilterOperator = "eq"
+	// FilterNe matches attributes not equal to the value.
+	FilterNe FilterOperator = "ne"
+	// FilterGt matches numbers, strings and times greater than the value.
+	FilterGt FilterOperator = "gt"
+	// FilterLt matches numbers, strings and times less than the value.
+	FilterLt FilterOperator = "lt"
+	// FilterIn matches attributes equal to one of the comma separated values.
+	FilterIn FilterOperator = "in"
+	// FilterContains matches strings holding the value, and lists holding an
+	// element equal to it.
+	FilterContains FilterOperator = "contains"
+)
+
+// filterOperators lists the supported operators.
+var filterOperators = []FilterOperator{
+	FilterEq, FilterNe, FilterGt, FilterLt, FilterIn, FilterContains,
+}
+
+// Filter is a condition requested with a filter query parameter.
+type Filter struct {
+	// Name is the name of the attribute to compare.
+	Name string
+	// Operator is the comparison to make.
+	Operator FilterOperator
+	// Value is the value to compare the attribute with, of the Go type of
+	// its field, or of the type of its elements for FilterContains on a
+	// list.  For FilterIn, it is a slice of such values.
+	Value interface{}
+}
+
+// ParseFilters reads the conditions requested with "filter[ATTRIBUTE]" and
+// "filter[ATTRIBUTE][OPERATOR]" query parameters, e.g.
+// "filter[status]=open&filter[created_at][gt]=1500000000", checking each of
+// them against the attr tags of model, which should be a pointer to a struct.
+// The filters are sorted by parameter name.
+//
+// Values are converted to the Go type of the attribute field as attributes
+// are by UnmarshalPayload, e.g. times are unix timestamps, or strings with the
+// iso8601 option.  A value is first read as a string, then as JSON, so that
+// filter[view_count]=10 gives an int for an int field.
+//
+// Unknown attributes and operators, and values that cannot be converted
+// result in a 400 Bad Request error object for each offending parameter,
+// returned together in QueryErrors.
+func ParseFilters(query url.Values, model interface{}) ([]Filter, error) {
+	modelType := reflect.TypeOf(model)
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+
+	schema, err := schemaOf(modelType)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if key == QueryParamFilter || strings.HasPrefix(key, QueryParamFilter+"[") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var filters []Filter
+	var errorObjects QueryErrors
+
+	for _, key := range keys {
+		for _, value := range query[key] {
+			filter, err := parseFilter(schema, key, value)
+			if err != nil {
+				errorObjects = append(errorObjects, err)
+				break
+			}
+
+			filters = append(filters, filter)
+		}
+	}
+
+	if errorObjects != nil {
+		return nil, errorObjects
+	}
+
+	return filters, nil
+}
+
+// parseFilter reads the filter query parameter key with the given value.
+func parseFilter(schema *modelSchema, key, value string) (Filter, *ErrorObject) {
+	name, operator, ok := filterKey(key)
+	if !ok {
+		return Filter{}, invalidFilter(key, fmt.Sprintf(
+			"%s must name an attribute, e.g. %s[title] or %s[title][eq]",
+			key,
+			QueryParamFilter,
+			QueryParamFilter,
+		))
+	}
+
+	field, ok := schema.attributes[name]
+	if !ok {
+		return Filter{}, invalidFilter(key, fmt.Sprintf(
+			"%s is not an attribute of %s",
+			name,
+			schema.typ,
+		))
+	}
+
+	filter := Filter{Name: name, Operator: operator}
+
+	switch operator {
+	case FilterEq, FilterNe:
+		v, err := coerceFilterValue(schema, field, value, false)
+		if err != nil {
+			return Filter{}, invalidFilterValue(key, field, err)
+		}
+
+		filter.Value = v
+	case FilterGt, FilterLt:
+		v, err := coerceFilterValue(schema, field, value, false)
+		if err != nil {
+			return Filter{}, invalidFilterValue(key, field, err)
+		}
+		if !isOrdered(reflect.ValueOf(v)) {
+			return Filter{}, invalidFilter(key, fmt.Sprintf(
+				"%s cannot be compared with %s",
+				name,
+				operator,
+			))
+		}
+
+		filter.Value = v
+	case FilterIn:
+		values := reflect.MakeSlice(reflect.SliceOf(field.structField.Type), 0, 1)
+		for _, element := range strings.Split(value, ",") {
+			v, err := coerceFilterValue(schema, field, element, false)
+			if err != nil {
+				return Filter{}, invalidFilterValue(key, field, err)
+			}
+
+			values = reflect.Append(values, reflect.ValueOf(v))
+		}
+
+		filter.Value = values.Interface()
+	case FilterContains:
+		fieldType := field.structField.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		switch fieldType.Kind() {
+		case reflect.String:
+			filter.Value = value
+		case reflect.Slice, reflect.Array:
+			v, err := coerceFilterValue(schema, field, value, true)
+			if err != nil {
+				return Filter{}, invalidFilterValue(key, field, err)
+			}
+
+			filter.Value = v
+		default:
+			return Filter{}, invalidFilter(key, fmt.Sprintf(
+				"%s cannot be compared with %s",
+				name,
+				operator,
+			))
+		}
+	default:
+		supported := make([]string, len(filterOperators))
+		for i, operator := range filterOperators {
+			supported[i] = string(operator)
+		}
+
+		return Filter{}, invalidFilter(key, fmt.Sprintf(
+			"%s is not a filter operator, expected one of %s",
+			operator,
+			strings.Join(supported, ", "),
+		))
+	}
+
+	return filter, nil
+}
+
+// filterKey splits a filter query parameter name into the attribute name and
+// the operator, FilterEq if it has none.
+func filterKey(key string) (string, FilterOperator, bool) {
+	rest := key[len(QueryParamFilter):]
+
+	end := strings.Index(rest, "]")
+	if end < 0 {
+		return "", "", false
+	}
+
+	name, ok := bracketed(rest[:end+1])
+	if !ok || name == "" {
+		return "", "", false
+	}
+
+	if rest = rest[end+1:]; rest == "" {
+		return name, FilterEq, true
+	}
+
+	operator, ok := bracketed(rest)
+	if !ok {
+		return "", "", false
+	}
+
+	return name, FilterOperator(operator), true
+}
+
+// coerceFilterValue converts value into the Go type of the attr field by
+// unmarshaling it as the attribute of a resource object, as a string, or else
+// as JSON.  With element set, value is converted into an element of the list
+// held by the field.
+func coerceFilterValue(schema *modelSchema, field *fieldSchema, value string,
+	element bool) (interface{}, error) {
+	candidates := []interface{}{value}
+
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err == nil && decoded != nil {
+		candidates = append(candidates, decoded)
+	}
+
+	var err error
+	for _, candidate := range candidates {
+		attribute := candidate
+		if element {
+			attribute = []interface{}{candidate}
+		}
+
+		model := reflect.New(schema.modelType)
+		node := &Node{
+			Type:       schema.typ,
+			Attributes: map[string]interface{}{field.name: attribute},
+		}
+
+		if err = unmarshalNode(node, model, nil, ""); err != nil {
+			continue
+		}
+
+		v := model.Elem().Field(field.index)
+		if element {
+			v = reflect.Indirect(v)
+			if v.Len() == 0 {
+				err = ErrInvalidType
+				continue
+			}
+			v = v.Index(0)
+		}
+
+		return v.Interface(), nil
+	}
+
+	var unmarshalError *UnmarshalError
+	if errors.As(err, &unmarshalError) {
+		err = unmarshalError.Err
+	}
+
+	return nil, err
+}
+
+// isOrdered reports whether v, or the value it points to, is a number, a
+// string or a time.
+func isOrdered(v reflect.Value) bool {
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return false
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return true
+	}
+
+	return v.Type() == reflect.TypeOf(time.Time{})
+}
+
+// invalidFilter returns the error reported for a filter query parameter that
+// cannot be honoured.
+func invalidFilter(key, detail string) *ErrorObject {
+	return &ErrorObject{
+		Status: "400",
+		Title:  "Invalid Query Parameter",
+		Detail: detail,
+		Source: &ErrorSource{Parameter: key},
+	}
+}
+
+// invalidFilterValue returns the error reported for a filter value that cannot
+// be converted to the type of its attribute.
+func invalidFilterValue(key string, field *fieldSchema, err error) *ErrorObject {
+	return invalidFilter(key, fmt.Sprintf(
+		"%s is not a valid %v: %v",
+		key,
+		field.structField.Type,
+		err,
+	))
+}
@@ -0,0 +1,115 @@
+package jsonapi
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseFilters(t *testing.T) {
+	query, _ := url.ParseQuery(
+		"filter[title]=Go&filter[view_count][gt]=10&filter[view_count][lt]=100" +
+			"&filter[created_at][gt]=1500000000&filter[current_post_id][in]=1,2" +
+			"&filter[title][contains]=o&sort=title",
+	)
+
+	filters, err := ParseFilters(query, new(Blog))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Filter{
+		{Name: "created_at", Operator: FilterGt, Value: time.Unix(1500000000, 0)},
+		{Name: "current_post_id", Operator: FilterIn, Value: []int{1, 2}},
+		{Name: "title", Operator: FilterEq, Value: "Go"},
+		{Name: "title", Operator: FilterContains, Value: "o"},
+		{Name: "view_count", Operator: FilterGt, Value: 10},
+		{Name: "view_count", Operator: FilterLt, Value: 100},
+	}
+	if !reflect.DeepEqual(expected, filters) {
+		t.Fatalf("Was expecting\n%#v\ngot\n%#v", expected, filters)
+	}
+}
+
+func TestParseFiltersConversions(t *testing.T) {
+	next := time.Date(2017, 7, 14, 2, 40, 0, 0, time.UTC)
+	carMake, year := "Tesla", uint(2017)
+
+	for _, test := range []struct {
+		model  interface{}
+		query  url.Values
+		filter Filter
+	}{
+		{
+			new(Timestamp),
+			url.Values{"filter[next][lt]": {"2017-07-14T02:40:00Z"}},
+			Filter{Name: "next", Operator: FilterLt, Value: &next},
+		},
+		{
+			new(Car),
+			url.Values{"filter[make][ne]": {"Tesla"}},
+			Filter{Name: "make", Operator: FilterNe, Value: &carMake},
+		},
+		{
+			new(Car),
+			url.Values{"filter[year]": {"2017"}},
+			Filter{Name: "year", Operator: FilterEq, Value: &year},
+		},
+		{
+			new(Venue),
+			url.Values{"filter[ratings][contains]": {"5"}},
+			Filter{Name: "ratings", Operator: FilterContains, Value: 5},
+		},
+	} {
+		filters, err := ParseFilters(test.query, test.model)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual([]Filter{test.filter}, filters) {
+			t.Fatalf("Was expecting %#v, got %#v", test.filter, filters)
+		}
+	}
+}
+
+func TestParseFiltersInvalid(t *testing.T) {
+	query := url.Values{
+		"filter":                       {"Go"},
+		"filter[subtitle]":             {"Go"},
+		"filter[title][like]":          {"Go"},
+		"filter[view_count]":           {"many"},
+		"filter[view_count][in]":       {"1,two"},
+		"filter[created_at][gt]":       {"yesterday"},
+		"filter[view_count][contains]": {"1"},
+		"filter[title]x":               {"Go"},
+	}
+
+	_, err := ParseFilters(query, new(Blog))
+
+	queryErrors, ok := err.(QueryErrors)
+	if !ok {
+		t.Fatalf("Was expecting QueryErrors, got %v", err)
+	}
+
+	expected := []string{
+		"filter",
+		"filter[created_at][gt]",
+		"filter[subtitle]",
+		"filter[title][like]",
+		"filter[title]x",
+		"filter[view_count]",
+		"filter[view_count][contains]",
+		"filter[view_count][in]",
+	}
+	actual := make([]string, len(queryErrors))
+	for i, errorObject := range queryErrors {
+		if errorObject.Status != "400" {
+			t.Fatalf("Was expecting a 400 error object, got %+v", errorObject)
+		}
+		actual[i] = errorObject.Source.Parameter
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Was expecting errors for\n%v\ngot\n%v", expected, actual)
+	}
+}
@@ -0,0 +1,105 @@
+//go:build go1.18
+
+package jsonapi
+
+import (
+	"io"
+	"reflect"
+)
+
+// Marshal writes a JSON API response for a single model, see
+// MarshalOnePayload.
+func Marshal[T any](w io.Writer, model *T, opts ...MarshalOption) error {
+	return MarshalOnePayload(w, model, opts...)
+}
+
+// MarshalSlice writes a JSON API response for a slice of models, see
+// MarshalManyPayload.  Unlike MarshalMany, it takes the models as they are
+// rather than as a []interface{}, e.g.
+//
+//	blogs, err := store.ListBlogs() // []*Blog
+//	...
+//	jsonapi.MarshalSlice(w, blogs)
+func MarshalSlice[T any](w io.Writer, models []*T, opts ...MarshalOption) error {
+	return MarshalManyPayload(w, models, opts...)
+}
+
+// Unmarshal reads a JSON API request for a single model of type T, see
+// UnmarshalPayload, e.g.
+//
+//	blog, err := jsonapi.Unmarshal[Blog](r.Body)
+func Unmarshal[T any](in io.Reader, opts ...UnmarshalOption) (*T, error) {
+	model := new(T)
+	if err := UnmarshalPayload(in, model, opts...); err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// UnmarshalMany reads a JSON API request for a slice of models of type T, see
+// UnmarshalManyPayload, e.g.
+//
+//	blogs, err := jsonapi.UnmarshalMany[Blog](r.Body)
+func UnmarshalMany[T any](in io.Reader, opts ...UnmarshalOption) ([]*T, error) {
+	payload, err := UnmarshalManyPayload(in, reflect.TypeOf(new(T)), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	models := make([]*T, len(payload))
+	for i, model := range payload {
+		models[i] = model.(*T)
+	}
+
+	return models, nil
+}
+
+// MarshalChan writes a jsonapi response with many records, received from
+// models until the channel is closed, using an Encoder.  On error,
+// MarshalChan keeps receiving from models, discarding them, until the channel
+// is closed, so that the producer does not block forever; producers of long
+// or endless sequences should be cancelled by the caller instead, e.g. through
+// a context.
+func MarshalChan[T any](w io.Writer, models <-chan *T, opts ...MarshalOption) error {
+	enc := NewEncoder(w, opts...)
+
+	for model := range models {
+		if err := enc.Encode(model); err != nil {
+			for range models {
+			}
+			return err
+		}
+	}
+
+	return enc.Close()
+}
+
+// UnmarshalEach reads a jsonapi request with many records of type T, calling
+// fn with each of them as soon as it is unmarshaled, see Decoder.  Give the
+// StreamData option for records to be unmarshaled as they are read from a
+// document without "included".
+func UnmarshalEach[T any](in io.Reader, fn func(model *T) error,
+	opts ...UnmarshalOption) error {
+	return NewDecoder(in, opts...).DecodeEach(reflect.TypeOf(new(T)), func(model interface{}) error {
+		return fn(model.(*T))
+	})
+}
+
+// ApplyQuery runs q against models, see Query.Apply, returning the records of
+// the requested page as a []*T along with the result, e.g.
+//
+//	blogs, result, err := jsonapi.ApplyQuery(query, store.AllBlogs())
+func ApplyQuery[T any](q *Query, models []*T) ([]*T, *QueryResult, error) {
+	result, err := q.Apply(models)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page := make([]*T, len(result.Models))
+	for i, model := range result.Models {
+		page[i] = model.(*T)
+	}
+
+	return page, result, nil
+}
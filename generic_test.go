@@ -0,0 +1,126 @@
+//go:build go1.18
+
+package jsonapi
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshalUnmarshalGeneric(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := Marshal(out, &Book{ID: 1, Title: "Go", Author: "Rob"}); err != nil {
+		t.Fatal(err)
+	}
+
+	book, err := Unmarshal[Book](out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.ID != 1 || book.Title != "Go" || book.Author != "Rob" {
+		t.Fatalf("Was expecting the book to round trip, got %#v", book)
+	}
+}
+
+func TestMarshalSliceUnmarshalManyGeneric(t *testing.T) {
+	books := []*Book{
+		{ID: 1, Title: "Go", Author: "Rob"},
+		{ID: 2, Title: "C", Author: "Dennis"},
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalSlice(out, books, WithFields(map[string][]string{"books": {"title"}})); err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := UnmarshalMany[Book](out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*Book{{ID: 1, Title: "Go"}, {ID: 2, Title: "C"}}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Was expecting %#v, got %#v", expected, actual)
+	}
+}
+
+func TestUnmarshalGenericError(t *testing.T) {
+	book, err := Unmarshal[Book](strings.NewReader(`{"data": {"type": "blogs", "id": "1"}}`))
+	if err == nil || book != nil {
+		t.Fatalf("Was expecting an error for a mismatched type, got %#v, %v", book, err)
+	}
+}
+
+func TestMarshalChan(t *testing.T) {
+	books := make(chan *Book)
+	go func() {
+		defer close(books)
+		for i := uint64(1); i <= 3; i++ {
+			books <- &Book{ID: i}
+		}
+	}()
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalChan(out, books); err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := UnmarshalMany[Book](out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actual) != 3 || actual[2].ID != 3 {
+		t.Fatalf("Was expecting the 3 books back, got %v", actual)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestMarshalChanDrainsOnError(t *testing.T) {
+	books := make(chan *Book)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(books)
+		for i := uint64(1); i <= 3; i++ {
+			books <- &Book{ID: i}
+		}
+	}()
+
+	if err := MarshalChan(failingWriter{}, books); err == nil {
+		t.Fatal("Was expecting the write error")
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Was expecting the producer not to be blocked")
+	}
+}
+
+func TestApplyQuery(t *testing.T) {
+	query := &Query{
+		Sort:    []SortField{{Name: "title"}},
+		Filters: []Filter{{Name: "author", Operator: FilterNe, Value: "Rob"}},
+	}
+
+	books, result, err := ApplyQuery(query, []*Book{
+		{ID: 1, Title: "Go", Author: "Rob"},
+		{ID: 2, Title: "C", Author: "Dennis"},
+		{ID: 3, Title: "AWK", Author: "Brian"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 2 || books[0].ID != 3 || books[1].ID != 2 || result.Total != 2 {
+		t.Fatalf("Was expecting books 3 and 2, got %v", books)
+	}
+}
@@ -0,0 +1,152 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// includeTree holds a set of relationship paths, keyed by the relationship
+// name at each level, e.g. "posts.comments,current_post" becomes
+// {"posts": {"comments": {}}, "current_post": {}}.
+type includeTree map[string]includeTree
+
+// WithInclude returns a MarshalOption restricting the records sideloaded into
+// "included" to those reached through the given relationship paths, e.g.
+//
+//	jsonapi.MarshalOnePayload(w, blog, jsonapi.WithInclude("posts.comments", "current_post"))
+//
+// A path includes every relationship along the way, so "posts.comments"
+// includes the posts as well as their comments.  Relationships that are not
+// on a path are still marshaled with their resource linkage, but their records
+// are not traversed.  Calling WithInclude without any paths includes nothing.
+//
+// http://jsonapi.org/format/#fetching-includes
+func WithInclude(paths ...string) MarshalOption {
+	return func(o *marshalOptions) {
+		if o.include == nil {
+			o.include = includeTree{}
+		}
+
+		for _, path := range paths {
+			if path == "" {
+				continue
+			}
+
+			tree := o.include
+			for _, relation := range strings.Split(path, ".") {
+				if tree[relation] == nil {
+					tree[relation] = includeTree{}
+				}
+				tree = tree[relation]
+			}
+		}
+	}
+}
+
+// ParseInclude reads the relationship paths requested with the "include"
+// query parameter, e.g. "include=posts.comments,current_post", checking each
+// of them against the relation tags of model, which should be a pointer to a
+// struct.  The paths can be given to WithInclude.
+//
+// ParseInclude returns nil when there is no include parameter, and a 400 Bad
+// Request *ErrorObject when a path does not name a relationship.
+func ParseInclude(query url.Values, model interface{}) ([]string, error) {
+	values, ok := query[QueryParamInclude]
+	if !ok {
+		return nil, nil
+	}
+
+	modelType := reflect.TypeOf(model)
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+
+	paths := []string{}
+	for _, value := range values {
+		for _, path := range strings.Split(value, ",") {
+			if path = strings.TrimSpace(path); path == "" {
+				continue
+			}
+
+			if err := checkIncludePath(modelType, path); err != nil {
+				return nil, err
+			}
+
+			paths = append(paths, path)
+		}
+	}
+
+	return paths, nil
+}
+
+// checkIncludePath ensures that every segment of path names a relationship
+// of the struct type reached through the previous segments.  Past a
+// polymorphic relationship, the rest of the path should be valid for one of
+// the registered types implementing its interface.
+func checkIncludePath(modelType reflect.Type, path string) error {
+	relations := strings.Split(path, ".")
+
+	for i, relation := range relations {
+		relatedType := relationType(modelType, relation)
+		if relatedType == nil {
+			schema, _ := schemaOf(modelType)
+
+			return invalidIncludePath(fmt.Sprintf(
+				"%s is not a relationship of %s, in include path %s",
+				relation,
+				schema.typ,
+				path,
+			))
+		}
+
+		if relatedType.Kind() == reflect.Interface && i < len(relations)-1 {
+			rest := strings.Join(relations[i+1:], ".")
+			for _, implementation := range registeredImplementations(relatedType) {
+				if checkIncludePath(implementation, rest) == nil {
+					return nil
+				}
+			}
+
+			return invalidIncludePath(fmt.Sprintf(
+				"%s is not a relationship of any resource of %s, in include path %s",
+				relations[i+1],
+				relation,
+				path,
+			))
+		}
+
+		modelType = relatedType
+	}
+
+	return nil
+}
+
+// invalidIncludePath returns the error reported for an include path that does
+// not name a relationship.
+func invalidIncludePath(detail string) *ErrorObject {
+	return &ErrorObject{
+		Status: "400",
+		Title:  "Invalid Query Parameter",
+		Detail: detail,
+		Source: &ErrorSource{Parameter: QueryParamInclude},
+	}
+}
+
+// relationType returns the struct type of the records of the named
+// relationship of modelType, or its interface type if the relationship is
+// polymorphic, or nil if modelType has no such relationship.
+func relationType(modelType reflect.Type, relation string) reflect.Type {
+	schema, err := schemaOf(modelType)
+	if err != nil {
+		return nil
+	}
+
+	field, ok := schema.relations[relation]
+	if !ok {
+		return nil
+	}
+
+	return field.relatedType()
+}
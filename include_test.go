@@ -0,0 +1,134 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func includedKeys(nodes []*Node) []string {
+	keys := []string{}
+	for _, n := range nodes {
+		keys = append(keys, n.Type+","+n.ID)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func TestWithIncludeSideloadsOnlyRequestedPaths(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := MarshalOnePayload(out, testBlog(), WithInclude("current_post")); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(OnePayload)
+	if err := json.NewDecoder(out).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := []string{"posts,1"}, includedKeys(resp.Included); !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting included %v, got %v", e, a)
+	}
+
+	// Relationships that were not included still carry their linkage
+	posts := resp.Data.Relationships["posts"].(map[string]interface{})["data"].([]interface{})
+	if len(posts) != 2 {
+		t.Fatalf("Was expecting the linkage of 2 posts, got %d", len(posts))
+	}
+	if posts[0].(map[string]interface{})["id"] != "1" {
+		t.Fatalf("Was expecting the post linkage to carry an id, got %v", posts[0])
+	}
+
+	// The included current post carries the linkage of its own relationships
+	currentPost := resp.Included[0]
+	if currentPost.Relationships["comments"] == nil {
+		t.Fatalf("Was expecting the included post to have its comments linkage")
+	}
+}
+
+func TestWithIncludeNestedPath(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := MarshalManyPayload(out, []*Blog{testBlog()}, WithInclude("posts.comments")); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(ManyPayload)
+	if err := json.NewDecoder(out).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"comments,1", "comments,2", "comments,3", "posts,1", "posts,2"}
+	if a := includedKeys(resp.Included); !reflect.DeepEqual(expected, a) {
+		t.Fatalf("Was expecting included %v, got %v", expected, a)
+	}
+}
+
+func TestWithIncludeNothing(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := MarshalOnePayload(out, testBlog(), WithInclude()); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(OnePayload)
+	if err := json.NewDecoder(out).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Included != nil {
+		t.Fatalf("Was expecting nothing to be included, got %v", includedKeys(resp.Included))
+	}
+	if resp.Data.Relationships["current_post"] == nil {
+		t.Fatalf("Was expecting the current_post linkage")
+	}
+}
+
+func TestParseInclude(t *testing.T) {
+	paths, err := ParseInclude(url.Values{
+		"include": {"posts.comments,current_post.latest_comment"},
+	}, new(Blog))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e := []string{"posts.comments", "current_post.latest_comment"}; !reflect.DeepEqual(e, paths) {
+		t.Fatalf("Was expecting %v, got %v", e, paths)
+	}
+
+	paths, err = ParseInclude(url.Values{}, new(Blog))
+	if err != nil || paths != nil {
+		t.Fatalf("Was expecting no paths without an include parameter, got %v, %v", paths, err)
+	}
+}
+
+func TestParseIncludeRejectsUnknownPaths(t *testing.T) {
+	for _, include := range []string{"authors", "posts.title", "posts.comments.posts"} {
+		_, err := ParseInclude(url.Values{"include": {include}}, new(Blog))
+
+		errorObject, ok := err.(*ErrorObject)
+		if !ok {
+			t.Fatalf("Was expecting an *ErrorObject for %s, got %v", include, err)
+		}
+		if errorObject.Status != "400" || errorObject.Source.Parameter != "include" {
+			t.Fatalf("Unexpected error object %#v", errorObject)
+		}
+	}
+}
+
+func TestParseIncludePolymorphicPaths(t *testing.T) {
+	paths, err := ParseInclude(url.Values{"include": {"attachments.thumbnail"}}, new(Message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := []string{"attachments.thumbnail"}; !reflect.DeepEqual(e, paths) {
+		t.Fatalf("Was expecting %v, got %v", e, paths)
+	}
+
+	_, err = ParseInclude(url.Values{"include": {"attachments.author"}}, new(Message))
+	if errorObject, ok := err.(*ErrorObject); !ok || errorObject.Status != "400" {
+		t.Fatalf("Was expecting a 400 *ErrorObject, got %v", err)
+	}
+}
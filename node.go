@@ -8,6 +8,7 @@ type OnePayload struct {
 	Data     *Node   `json:"data"`
 	Included []*Node `json:"included,omitempty"`
 	Links    *Links  `json:"links,omitempty"`
+	Meta     *Meta   `json:"meta,omitempty"`
 }
 
 // ManyPayload is used to represent a generic JSON API payload where many
@@ -16,6 +17,7 @@ type ManyPayload struct {
 	Data     []*Node `json:"data"`
 	Included []*Node `json:"included,omitempty"`
 	Links    *Links  `json:"links,omitempty"`
+	Meta     *Meta   `json:"meta,omitempty"`
 }
 
 // Node is used to represent a generic JSON API Resource
@@ -26,12 +28,14 @@ type Node struct {
 	Attributes    map[string]interface{} `json:"attributes,omitempty"`
 	Relationships map[string]interface{} `json:"relationships,omitempty"`
 	Links         *Links                 `json:"links,omitempty"`
+	Meta          *Meta                  `json:"meta,omitempty"`
 }
 
 // RelationshipOneNode is used to represent a generic has one JSON API relation
 type RelationshipOneNode struct {
 	Data  *Node  `json:"data"`
 	Links *Links `json:"links,omitempty"`
+	Meta  *Meta  `json:"meta,omitempty"`
 }
 
 // RelationshipManyNode is used to represent a generic has many JSON API
@@ -39,6 +43,7 @@ type RelationshipOneNode struct {
 type RelationshipManyNode struct {
 	Data  []*Node `json:"data"`
 	Links *Links  `json:"links,omitempty"`
+	Meta  *Meta   `json:"meta,omitempty"`
 }
 
 // Links is used to represent a `links` object.
@@ -85,3 +90,78 @@ type RelationshipLinkable interface {
 	// JSONAPIRelationshipLinks will be invoked for each relationship with the corresponding relation name (e.g. `comments`)
 	JSONAPIRelationshipLinks(relation string) *Links
 }
+
+// Meta is used to represent a `meta` object.
+// http://jsonapi.org/format/#document-meta
+type Meta map[string]interface{}
+
+// Metable is used to include document meta in response data
+// e.g. {"foo": "bar"}
+type Metable interface {
+	JSONAPIMeta() *Meta
+}
+
+// RelationshipMetable is used to include relationship meta in response data
+// e.g. {"count": 5}
+type RelationshipMetable interface {
+	// JSONAPIRelationshipMeta will be invoked for each relationship with the corresponding relation name (e.g. `comments`)
+	JSONAPIRelationshipMeta(relation string) *Meta
+}
+
+// MetaUnmarshaler is used to receive the `meta` object of a resource when
+// request data is unmarshaled into a model
+type MetaUnmarshaler interface {
+	UnmarshalJSONAPIMeta(meta *Meta) error
+}
+
+// RelationshipMetaUnmarshaler is used to receive the `meta` object of each
+// relationship when request data is unmarshaled into a model
+type RelationshipMetaUnmarshaler interface {
+	// UnmarshalJSONAPIRelationshipMeta will be invoked for each relationship with the corresponding relation name (e.g. `comments`)
+	UnmarshalJSONAPIRelationshipMeta(relation string, meta *Meta) error
+}
+
+// AttributeMarshaler is implemented by attribute types that need a different
+// representation in JSON API documents than their JSON one
+// e.g. a decimal marshaled as a string
+type AttributeMarshaler interface {
+	MarshalJSONAPIAttribute() (interface{}, error)
+}
+
+// AttributeUnmarshaler is implemented by attribute types that need a
+// different representation in JSON API documents than their JSON one; it
+// receives the decoded JSON value of the attribute
+type AttributeUnmarshaler interface {
+	UnmarshalJSONAPIAttribute(value interface{}) error
+}
+
+// IDMarshaler is implemented by primary field types that format their own
+// resource id e.g. a composite key
+type IDMarshaler interface {
+	MarshalJSONAPIID() (string, error)
+}
+
+// IDUnmarshaler is implemented by primary field types that parse their own
+// resource id e.g. a composite key
+type IDUnmarshaler interface {
+	UnmarshalJSONAPIID(id string) error
+}
+
+// ResourceIdentifier is used as the type of relation fields, *ResourceIdentifier
+// or []*ResourceIdentifier, that hold the resource linkage of a relationship
+// rather than the related records, e.g. to link existing records without
+// unmarshaling, or overwriting, them
+type ResourceIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Meta *Meta  `json:"meta,omitempty"`
+}
+
+// RelationshipIncludedUnmarshaler is used to learn, for each resource linkage
+// of each relationship, whether the related resource was sent in full, in the
+// "included" array or embedded, or as a bare resource identifier object, when
+// request data is unmarshaled into a model
+type RelationshipIncludedUnmarshaler interface {
+	// UnmarshalJSONAPIRelationshipIncluded will be invoked for each resource linkage with the corresponding relation name (e.g. `comments`)
+	UnmarshalJSONAPIRelationshipIncluded(relation string, identifier *ResourceIdentifier, included bool) error
+}
@@ -0,0 +1,345 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultPageSize is the page size of a Paginator without a DefaultSize.
+const defaultPageSize = 20
+
+// maxInt is the largest int, which math.MaxInt only names from Go 1.17.
+const maxInt = int(^uint(0) >> 1)
+
+// ErrInvalidPage is returned for a Page with a negative Offset or a Limit
+// that is not positive, which Paginator.Parse never returns.
+var ErrInvalidPage = errors.New("Invalid page")
+
+// PaginationStrategy selects the query parameters with which the pages of a
+// collection are requested.
+type PaginationStrategy int
+
+const (
+	// PageNumberPagination requests pages with page[number], from 1, and
+	// page[size].
+	PageNumberPagination PaginationStrategy = iota
+	// OffsetPagination requests pages with page[offset], the index of the
+	// first record from 0, and page[limit].
+	OffsetPagination
+	// CursorPagination requests pages with page[size] and either
+	// page[after] or page[before], the cursor of the record the page
+	// follows or precedes, see EncodeCursor.
+	CursorPagination
+)
+
+// Paginator reads the page of a collection requested with the pagination
+// query parameters of its strategy, e.g. in a list handler,
+//
+//	paginator := &jsonapi.Paginator{DefaultSize: 20, MaxSize: 100}
+//
+//	page, err := paginator.Parse(r.URL.Query())
+//	if err != nil {
+//		// err is a 400 *jsonapi.ErrorObject
+//	}
+//
+//	// blogs is a []interface{} of *Blog
+//	blogs, total := store.ListBlogs(page.Offset, page.Limit)
+//
+//	payload, err := jsonapi.MarshalMany(blogs)
+//	// ...
+//	if err := page.Paginate(payload, r.URL, total); err != nil {
+//		// ...
+//	}
+//
+// http://jsonapi.org/format/#fetching-pagination
+type Paginator struct {
+	// Strategy selects the query parameters, PageNumberPagination by
+	// default.
+	Strategy PaginationStrategy
+	// DefaultSize is the number of records of a page when the request does
+	// not ask for one; 20 if zero, and at most MaxSize.
+	DefaultSize int
+	// MaxSize is the largest number of records a request can ask for, if
+	// not zero.
+	MaxSize int
+}
+
+// Page is a page of a collection, as requested from a Paginator.
+type Page struct {
+	// Offset is the index of the first record of the page, from 0.
+	Offset int
+	// Limit is the largest number of records of the page.
+	Limit int
+	// After is the cursor of the record the page follows, with
+	// CursorPagination.
+	After string
+	// Before is the cursor of the record the page precedes, with
+	// CursorPagination.
+	Before string
+
+	strategy PaginationStrategy
+}
+
+// Parse reads the page requested by the query parameters of the strategy of
+// the paginator, starting at the first page of DefaultSize records.  A page
+// parameter of another strategy, a page number or size that is not a positive
+// integer, a negative offset, a size larger than MaxSize, a page number whose
+// offset does not fit in an int, or a cursor that cannot be read results in a
+// 400 Bad Request *ErrorObject.
+func (p *Paginator) Parse(query url.Values) (*Page, error) {
+	position, size := QueryParamPageNumber, QueryParamPageSize
+	supported := []string{QueryParamPageNumber, QueryParamPageSize}
+	switch p.Strategy {
+	case OffsetPagination:
+		position, size = QueryParamPageOffset, QueryParamPageLimit
+		supported = []string{QueryParamPageOffset, QueryParamPageLimit}
+	case CursorPagination:
+		supported = []string{QueryParamPageAfter, QueryParamPageBefore, QueryParamPageSize}
+	}
+
+	for key := range query {
+		if strings.HasPrefix(key, "page[") && !contains(supported, key) {
+			return nil, invalidPageParameter(key, fmt.Sprintf(
+				"%s is not supported, pages are requested with %s",
+				key,
+				strings.Join(supported, ", "),
+			))
+		}
+	}
+
+	page := &Page{Limit: p.defaultSize(), strategy: p.Strategy}
+
+	limit, ok, err := pageParameter(query, size, 1)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if p.MaxSize > 0 && limit > p.MaxSize {
+			return nil, invalidPageParameter(size, fmt.Sprintf(
+				"%s must be at most %d",
+				size,
+				p.MaxSize,
+			))
+		}
+
+		page.Limit = limit
+	}
+
+	switch p.Strategy {
+	case OffsetPagination:
+		offset, _, err := pageParameter(query, position, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		page.Offset = offset
+	case CursorPagination:
+		page.After, page.Before = query.Get(QueryParamPageAfter), query.Get(QueryParamPageBefore)
+
+		if page.After != "" && page.Before != "" {
+			return nil, invalidPageParameter(QueryParamPageBefore, fmt.Sprintf(
+				"%s cannot be combined with %s",
+				QueryParamPageBefore,
+				QueryParamPageAfter,
+			))
+		}
+
+		for key, cursor := range map[string]string{
+			QueryParamPageAfter:  page.After,
+			QueryParamPageBefore: page.Before,
+		} {
+			if _, ok := decodeCursor(cursor); cursor != "" && !ok {
+				return nil, invalidPageParameter(key, fmt.Sprintf(
+					"%s is not a valid cursor",
+					key,
+				))
+			}
+		}
+	default:
+		number, ok, err := pageParameter(query, position, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			if number-1 > maxInt/page.Limit {
+				return nil, invalidPageParameter(position, fmt.Sprintf(
+					"%s is too large for pages of %d records",
+					position,
+					page.Limit,
+				))
+			}
+
+			page.Offset = (number - 1) * page.Limit
+		}
+	}
+
+	return page, nil
+}
+
+func (p *Paginator) defaultSize() int {
+	size := p.DefaultSize
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	if p.MaxSize > 0 && size > p.MaxSize {
+		size = p.MaxSize
+	}
+
+	return size
+}
+
+// pageParameter reads the integer value, of at least min, of the given page
+// query parameter, reporting whether it was given.
+func pageParameter(query url.Values, key string, min int) (int, bool, error) {
+	value := query.Get(key)
+	if value == "" {
+		return 0, false, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min {
+		return 0, false, invalidPageParameter(key, fmt.Sprintf(
+			"%s must be an integer of at least %d",
+			key,
+			min,
+		))
+	}
+
+	return n, true, nil
+}
+
+// invalidPageParameter returns the error reported for a pagination query
+// parameter that cannot be honoured.
+func invalidPageParameter(key, detail string) *ErrorObject {
+	return &ErrorObject{
+		Status: "400",
+		Title:  "Invalid Query Parameter",
+		Detail: detail,
+		Source: &ErrorSource{Parameter: key},
+	}
+}
+
+// Paginate adds the pagination links of the page, see Links, and the totals
+// of the collection, see Meta, to the top-level links and meta of payload.
+// For pages requested with CursorPagination, Paginate only adds the number of
+// records; their links are added by PaginateCursors.  An invalid page results
+// in an error wrapping ErrInvalidPage, and nothing is added.
+func (p *Page) Paginate(payload *ManyPayload, u *url.URL, total int) error {
+	if err := p.check(); err != nil {
+		return err
+	}
+
+	if payload.Links == nil {
+		payload.Links = &Links{}
+	}
+	for key, link := range *p.Links(u, total) {
+		(*payload.Links)[key] = link
+	}
+
+	if payload.Meta == nil {
+		payload.Meta = &Meta{}
+	}
+	for key, value := range *p.Meta(total) {
+		(*payload.Meta)[key] = value
+	}
+
+	return nil
+}
+
+// check returns an error wrapping ErrInvalidPage for a page with a negative
+// Offset or a Limit that is not positive.
+func (p *Page) check() error {
+	if p.Offset < 0 || p.Limit <= 0 {
+		return fmt.Errorf("%w: offset %d, limit %d", ErrInvalidPage, p.Offset, p.Limit)
+	}
+
+	return nil
+}
+
+// Links returns the first, last, prev and next links of the page, requested
+// with PageNumberPagination or OffsetPagination, of a collection of total
+// records, built from u, the URL of the request, by
+// setting its page query parameters and keeping the others, e.g. filters.
+// The prev and next links are left out on the first and last pages.
+//
+// u should be absolute for the links to be; the URL of a server request
+// usually holds its path and query only.  Pages requested with
+// CursorPagination, and invalid pages, have no such links, see
+// PaginateCursors.
+func (p *Page) Links(u *url.URL, total int) *Links {
+	if p.strategy == CursorPagination || p.check() != nil {
+		return &Links{}
+	}
+
+	links := Links{
+		KeyFirstPage: p.link(u, 0),
+		KeyLastPage:  p.link(u, p.lastOffset(total)),
+	}
+
+	if p.Offset > 0 {
+		prev := p.Offset - p.Limit
+		if prev < 0 {
+			prev = 0
+		}
+
+		links[KeyPreviousPage] = p.link(u, prev)
+	}
+	if p.Offset < total-p.Limit {
+		links[KeyNextPage] = p.link(u, p.Offset+p.Limit)
+	}
+
+	return &links
+}
+
+// Meta returns the number of records and of pages of a collection of total
+// records, or only the number of records for pages requested with
+// CursorPagination and for invalid pages.
+func (p *Page) Meta(total int) *Meta {
+	if p.strategy == CursorPagination || p.check() != nil {
+		return &Meta{KeyTotalRecords: total}
+	}
+
+	pages := total / p.Limit
+	if total%p.Limit != 0 {
+		pages++
+	}
+
+	return &Meta{
+		KeyTotalRecords: total,
+		KeyTotalPages:   pages,
+	}
+}
+
+// lastOffset returns the offset of the last page of a collection of total
+// records, keeping the pages aligned with the requested offset.
+func (p *Page) lastOffset(total int) int {
+	start := p.Offset % p.Limit
+	if total <= start {
+		return 0
+	}
+
+	return start + (total-1-start)/p.Limit*p.Limit
+}
+
+// link returns u with the page query parameters of the page starting at
+// offset.
+func (p *Page) link(u *url.URL, offset int) string {
+	query := u.Query()
+
+	if p.strategy == OffsetPagination {
+		query.Set(QueryParamPageOffset, strconv.Itoa(offset))
+		query.Set(QueryParamPageLimit, strconv.Itoa(p.Limit))
+	} else {
+		query.Set(QueryParamPageNumber, strconv.Itoa(offset/p.Limit+1))
+		query.Set(QueryParamPageSize, strconv.Itoa(p.Limit))
+	}
+
+	link := *u
+	link.RawQuery = query.Encode()
+
+	return link.String()
+}
@@ -0,0 +1,182 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestPaginatorParse(t *testing.T) {
+	numbers := &Paginator{DefaultSize: 10, MaxSize: 50}
+	offsets := &Paginator{Strategy: OffsetPagination, MaxSize: 50}
+
+	for _, test := range []struct {
+		paginator *Paginator
+		query     string
+		page      Page
+	}{
+		{numbers, "", Page{Offset: 0, Limit: 10}},
+		{numbers, "page[number]=3", Page{Offset: 20, Limit: 10}},
+		{numbers, "page[number]=3&page[size]=25&sort=title", Page{Offset: 50, Limit: 25}},
+		{offsets, "", Page{Offset: 0, Limit: 20}},
+		{offsets, "page[offset]=5&page[limit]=50", Page{Offset: 5, Limit: 50, strategy: OffsetPagination}},
+		{&Paginator{DefaultSize: 100, MaxSize: 30}, "", Page{Offset: 0, Limit: 30}},
+	} {
+		query, _ := url.ParseQuery(test.query)
+
+		page, err := test.paginator.Parse(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		test.page.strategy = test.paginator.Strategy
+		if !reflect.DeepEqual(test.page, *page) {
+			t.Fatalf("Was expecting %+v for %q, got %+v", test.page, test.query, *page)
+		}
+	}
+}
+
+func TestPaginatorParseInvalid(t *testing.T) {
+	numbers := &Paginator{MaxSize: 50}
+	offsets := &Paginator{Strategy: OffsetPagination}
+
+	for _, test := range []struct {
+		paginator *Paginator
+		query     string
+		parameter string
+	}{
+		{numbers, "page[number]=0", QueryParamPageNumber},
+		{numbers, "page[number]=1844674407370955162&page[size]=10", QueryParamPageNumber},
+		{&Paginator{}, "page[number]=" + strconv.Itoa(maxInt) + "&page[size]=2", QueryParamPageNumber},
+		{numbers, "page[number]=first", QueryParamPageNumber},
+		{numbers, "page[size]=51", QueryParamPageSize},
+		{numbers, "page[offset]=10", QueryParamPageOffset},
+		{offsets, "page[offset]=-1", QueryParamPageOffset},
+		{offsets, "page[limit]=0", QueryParamPageLimit},
+		{offsets, "page[size]=10", QueryParamPageSize},
+	} {
+		query, _ := url.ParseQuery(test.query)
+
+		_, err := test.paginator.Parse(query)
+
+		errorObject, ok := err.(*ErrorObject)
+		if !ok {
+			t.Fatalf("Was expecting an *ErrorObject for %q, got %v", test.query, err)
+		}
+		if errorObject.Status != "400" || errorObject.Source.Parameter != test.parameter {
+			t.Fatalf("Was expecting a 400 for %s, got %+v", test.parameter, errorObject)
+		}
+	}
+}
+
+func TestPageLinks(t *testing.T) {
+	u, _ := url.Parse("http://example.com/blogs?sort=title&page[number]=2&page[size]=10")
+	page := &Page{Offset: 10, Limit: 10}
+
+	expected := &Links{
+		KeyFirstPage:    "http://example.com/blogs?page%5Bnumber%5D=1&page%5Bsize%5D=10&sort=title",
+		KeyLastPage:     "http://example.com/blogs?page%5Bnumber%5D=6&page%5Bsize%5D=10&sort=title",
+		KeyPreviousPage: "http://example.com/blogs?page%5Bnumber%5D=1&page%5Bsize%5D=10&sort=title",
+		KeyNextPage:     "http://example.com/blogs?page%5Bnumber%5D=3&page%5Bsize%5D=10&sort=title",
+	}
+	if actual := page.Links(u, 57); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Was expecting %v, got %v", expected, actual)
+	}
+
+	// The last page has no next link, the first no prev link
+	page.Offset = 50
+	if _, ok := (*page.Links(u, 57))[KeyNextPage]; ok {
+		t.Fatal("Was expecting no next link on the last page")
+	}
+	page.Offset = 0
+	if _, ok := (*page.Links(u, 57))[KeyPreviousPage]; ok {
+		t.Fatal("Was expecting no prev link on the first page")
+	}
+
+	u, _ = url.Parse("/blogs")
+	page = &Page{Offset: 5, Limit: 10, strategy: OffsetPagination}
+
+	expected = &Links{
+		KeyFirstPage:    "/blogs?page%5Blimit%5D=10&page%5Boffset%5D=0",
+		KeyLastPage:     "/blogs?page%5Blimit%5D=10&page%5Boffset%5D=15",
+		KeyPreviousPage: "/blogs?page%5Blimit%5D=10&page%5Boffset%5D=0",
+		KeyNextPage:     "/blogs?page%5Blimit%5D=10&page%5Boffset%5D=15",
+	}
+	if actual := page.Links(u, 24); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Was expecting %v, got %v", expected, actual)
+	}
+
+	// Offsets and sizes near the largest int do not overflow
+	page = &Page{Offset: maxInt - 5, Limit: 10, strategy: OffsetPagination}
+	if _, ok := (*page.Links(u, 24))[KeyNextPage]; ok {
+		t.Fatal("Was expecting no next link past the last page")
+	}
+	page = &Page{Limit: maxInt}
+	if e, a := 1, (*page.Meta(24))[KeyTotalPages]; e != a {
+		t.Fatalf("Was expecting %d page, got %v", e, a)
+	}
+}
+
+func TestPagePaginate(t *testing.T) {
+	payload, err := MarshalMany([]interface{}{testBlog()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload.Meta = &Meta{"generated": true}
+
+	u, _ := url.Parse("/blogs")
+	page := &Page{Offset: 0, Limit: 1}
+	if err := page.Paginate(payload, u, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := json.NewEncoder(out).Encode(payload); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Links map[string]interface{} `json:"links"`
+		Meta  map[string]interface{} `json:"meta"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := "/blogs?page%5Bnumber%5D=2&page%5Bsize%5D=1", doc.Links[KeyNextPage]; e != a {
+		t.Fatalf("Was expecting the next link %s, got %v", e, a)
+	}
+	expectedMeta := map[string]interface{}{
+		"generated":     true,
+		KeyTotalRecords: float64(3),
+		KeyTotalPages:   float64(3),
+	}
+	if !reflect.DeepEqual(expectedMeta, doc.Meta) {
+		t.Fatalf("Was expecting the meta %v, got %v", expectedMeta, doc.Meta)
+	}
+}
+
+func TestPagePaginateInvalidPage(t *testing.T) {
+	u, _ := url.Parse("/blogs")
+
+	for _, page := range []*Page{{}, {Limit: -1}, {Offset: -1, Limit: 10}} {
+		payload := &ManyPayload{Data: []*Node{}}
+		if err := page.Paginate(payload, u, 10); !errors.Is(err, ErrInvalidPage) {
+			t.Fatalf("Was expecting ErrInvalidPage for %+v, got %v", page, err)
+		}
+		if payload.Links != nil || payload.Meta != nil {
+			t.Fatalf("Was expecting nothing to be added for %+v, got %v and %v", page, payload.Links, payload.Meta)
+		}
+
+		if links := page.Links(u, 10); len(*links) != 0 {
+			t.Fatalf("Was expecting no links for %+v, got %v", page, links)
+		}
+		if e, a := (&Meta{KeyTotalRecords: 10}), page.Meta(10); !reflect.DeepEqual(e, a) {
+			t.Fatalf("Was expecting the meta %v for %+v, got %v", e, page, a)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package jsonapi
+
+import (
+	"io"
+	"reflect"
+)
+
+// Presence lists the members of a resource object that were present in a
+// request, whether or not their value was null, among those matching the
+// attr and relation tags of the model it was unmarshaled into.
+type Presence struct {
+	// Attributes are the names of the attributes that were present.
+	Attributes []string
+	// Relationships are the names of the relationships that were present.
+	Relationships []string
+	// Fields are the names of the struct fields of those attributes and
+	// relationships, in struct order.
+	Fields []string
+}
+
+// HasAttribute reports whether the named attribute was present.
+func (p *Presence) HasAttribute(name string) bool {
+	return contains(p.Attributes, name)
+}
+
+// HasRelationship reports whether the named relationship was present.
+func (p *Presence) HasRelationship(name string) bool {
+	return contains(p.Relationships, name)
+}
+
+// HasField reports whether the member unmarshaled into the named struct field
+// was present.
+func (p *Presence) HasField(name string) bool {
+	return contains(p.Fields, name)
+}
+
+// UnmarshalPartialPayload does the same as UnmarshalPayload except it also
+// returns the attributes and relationships that were present in the request,
+// telling apart members that were absent, and left untouched, from those
+// explicitly set to their zero value or null, e.g. to apply a PATCH request
+// to a model loaded from your data store,
+//
+//	blog := store.FindBlog(id)
+//	presence, err := jsonapi.UnmarshalPartialPayload(r.Body, blog)
+//	...
+//	store.UpdateBlog(blog, presence.Fields)
+//
+// Unlike UnmarshalPayload, attributes and to-one relationships that are null
+// reset their field to its zero value.
+//
+// model interface{} should be a pointer to a struct.
+func UnmarshalPartialPayload(in io.Reader, model interface{},
+	opts ...UnmarshalOption) (*Presence, error) {
+	payload, err := unmarshalOnePayload(in, model, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	modelValue := reflect.ValueOf(model).Elem()
+
+	schema, err := schemaOf(modelValue.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	clearNulls(payload.Data, modelValue, schema)
+
+	return presenceOf(payload.Data, schema), nil
+}
+
+// clearNulls sets the fields of the attributes and to-one relationships that
+// are null in the resource object data to their zero value, as
+// UnmarshalPayload leaves them untouched.
+func clearNulls(data *Node, modelValue reflect.Value, schema *modelSchema) {
+	for _, field := range schema.fields {
+		var null bool
+
+		switch field.annotation {
+		case annotationAttribute:
+			value, ok := data.Attributes[field.name]
+			null = ok && value == nil
+		case annotationRelation:
+			relationship, ok := data.Relationships[field.name].(map[string]interface{})
+			if !ok || field.toMany {
+				continue
+			}
+			linkage, ok := relationship["data"]
+			null = ok && linkage == nil
+		}
+
+		if null {
+			fieldValue := modelValue.Field(field.index)
+			fieldValue.Set(reflect.Zero(fieldValue.Type()))
+		}
+	}
+}
+
+// presenceOf returns the members of the resource object data that match the
+// fields of schema.
+func presenceOf(data *Node, schema *modelSchema) *Presence {
+	presence := &Presence{
+		Attributes:    []string{},
+		Relationships: []string{},
+		Fields:        []string{},
+	}
+
+	for _, field := range schema.fields {
+		switch field.annotation {
+		case annotationAttribute:
+			if _, ok := data.Attributes[field.name]; !ok {
+				continue
+			}
+			presence.Attributes = append(presence.Attributes, field.name)
+		case annotationRelation:
+			if _, ok := data.Relationships[field.name]; !ok {
+				continue
+			}
+			presence.Relationships = append(presence.Relationships, field.name)
+		default:
+			continue
+		}
+
+		presence.Fields = append(presence.Fields, field.structField.Name)
+	}
+
+	return presence
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalPartialPayload(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": {
+			"type": "blogs",
+			"id": "1",
+			"attributes": {
+				"title": "",
+				"view_count": 0,
+				"unknown": "ignored"
+			},
+			"relationships": {
+				"current_post": {"data": {"type": "posts", "id": "2"}}
+			}
+		}
+	}`)
+	blog := &Blog{ID: 1, Title: "Stored", ViewCount: 10, CurrentPostID: 3}
+
+	presence, err := UnmarshalPartialPayload(in, blog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Presence{
+		Attributes:    []string{"title", "view_count"},
+		Relationships: []string{"current_post"},
+		Fields:        []string{"Title", "CurrentPost", "ViewCount"},
+	}
+	if !reflect.DeepEqual(expected, presence) {
+		t.Fatalf("Was expecting %#v, got %#v", expected, presence)
+	}
+
+	if !presence.HasAttribute("title") || presence.HasAttribute("current_post_id") ||
+		!presence.HasRelationship("current_post") || presence.HasRelationship("posts") ||
+		!presence.HasField("ViewCount") || presence.HasField("CurrentPostID") {
+		t.Fatalf("Unexpected presence lookups for %#v", presence)
+	}
+
+	if blog.Title != "" || blog.ViewCount != 0 || blog.CurrentPostID != 3 {
+		t.Fatalf("Was expecting only the present attributes to be set, got %#v", blog)
+	}
+}
+
+func TestUnmarshalPartialPayloadNulls(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": {
+			"type": "posts",
+			"id": "1",
+			"attributes": {"title": null},
+			"relationships": {"latest_comment": {"data": null}}
+		}
+	}`)
+	post := &MetaPost{ID: 1, Title: "Stored", LatestComment: &Comment{ID: 2}}
+
+	presence, err := UnmarshalPartialPayload(in, post)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e := []string{"Title", "LatestComment"}; !reflect.DeepEqual(e, presence.Fields) {
+		t.Fatalf("Was expecting fields %v, got %v", e, presence.Fields)
+	}
+	if post.Title != "" || post.LatestComment != nil {
+		t.Fatalf("Was expecting null members to reset their fields, got %#v", post)
+	}
+}
@@ -0,0 +1,568 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Query is the page of a collection requested by the sort, filter and
+// pagination query parameters of a list request, as read by ParseQuery.  For
+// tests and small services, Apply runs the query against the records of a
+// slice, e.g.
+//
+//	query, err := jsonapi.ParseQuery(r.URL.Query(), new(Blog), paginator)
+//	if err != nil {
+//		// err is a jsonapi.QueryErrors of 400 error objects
+//	}
+//
+//	result, err := query.Apply(blogs) // blogs is a []*Blog
+//	// ...
+//	payload, err := jsonapi.MarshalMany(result.Models)
+//	// ...
+//	if err := query.Paginate(payload, r.URL, result); err != nil {
+//		// ...
+//	}
+type Query struct {
+	// Sort are the fields the collection is ordered by, see ParseSort.
+	Sort []SortField
+	// Filters are the conditions records should meet, see ParseFilters.
+	Filters []Filter
+	// Page is the requested page, or nil for all of the records.
+	Page *Page
+}
+
+// QueryResult holds the records selected by Query.Apply.
+type QueryResult struct {
+	// Models are the records of the page, in order.
+	Models []interface{}
+	// Total is the number of records meeting the filters.
+	Total int
+	// More reports whether there are records past a page requested with
+	// CursorPagination, in the direction it was requested in, see
+	// PaginateCursors.
+	More bool
+}
+
+// ParseQuery reads the sort, filter and, if paginator is not nil, pagination
+// query parameters of a request for a collection of model, which should be a
+// pointer to a struct, with ParseSort, ParseFilters and Paginator.Parse.  With
+// CursorPagination, the collection can only be sorted by attributes of model,
+// which the cursors are built from.
+//
+// Invalid query parameters result in a 400 Bad Request error object for each
+// of them, returned together in QueryErrors.
+func ParseQuery(query url.Values, model interface{},
+	paginator *Paginator) (*Query, error) {
+	q := &Query{}
+	var errorObjects QueryErrors
+
+	collect := func(err error) error {
+		switch err := err.(type) {
+		case nil:
+		case *ErrorObject:
+			errorObjects = append(errorObjects, err)
+		case QueryErrors:
+			errorObjects = append(errorObjects, err...)
+		default:
+			return err
+		}
+
+		return nil
+	}
+
+	sortFields, err := ParseSort(query, model)
+	if err := collect(err); err != nil {
+		return nil, err
+	}
+	q.Sort = sortFields
+
+	filters, err := ParseFilters(query, model)
+	if err := collect(err); err != nil {
+		return nil, err
+	}
+	q.Filters = filters
+
+	if paginator != nil {
+		page, err := paginator.Parse(query)
+		if err := collect(err); err != nil {
+			return nil, err
+		}
+		q.Page = page
+
+		if page != nil && page.strategy == CursorPagination && errorObjects == nil {
+			collect(q.checkCursors(model))
+		}
+	}
+
+	if errorObjects != nil {
+		return nil, errorObjects
+	}
+
+	return q, nil
+}
+
+// checkCursors ensures that the collection is only sorted by attributes, and
+// that the cursor of the page can be read for those attributes.
+func (q *Query) checkCursors(model interface{}) error {
+	modelType := reflect.TypeOf(model)
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+
+	for _, field := range q.Sort {
+		if strings.Contains(field.Name, ".") {
+			return invalidSortField(fmt.Sprintf(
+				"%s is not an attribute, pages requested with cursors are sorted by attributes",
+				field.Name,
+			))
+		}
+	}
+
+	for key, cursor := range map[string]string{
+		QueryParamPageAfter:  q.Page.After,
+		QueryParamPageBefore: q.Page.Before,
+	} {
+		if cursor == "" {
+			continue
+		}
+
+		if err := DecodeCursor(cursor, reflect.New(modelType).Interface(),
+			q.sortAttributes()...); err != nil {
+			return invalidPageParameter(key, fmt.Sprintf(
+				"%s is not a valid cursor for sort=%s",
+				key,
+				q.sortParameter(),
+			))
+		}
+	}
+
+	return nil
+}
+
+// sortAttributes returns the names of the sort fields, the attributes cursors
+// are built from.
+func (q *Query) sortAttributes() []string {
+	attributes := make([]string, len(q.Sort))
+	for i, field := range q.Sort {
+		attributes[i] = field.Name
+	}
+
+	return attributes
+}
+
+// sortParameter returns the value of the sort query parameter of the query.
+func (q *Query) sortParameter() string {
+	fields := make([]string, len(q.Sort))
+	for i, field := range q.Sort {
+		fields[i] = field.Name
+		if field.Desc {
+			fields[i] = "-" + field.Name
+		}
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// Apply runs the query against models, a slice of pointers to jsonapi tagged
+// structs, reading their attributes through their tags as MarshalManyPayload
+// does.  The records meeting every filter are ordered by the sort fields, and
+// by id between records that are otherwise equal, then the requested page of
+// them is returned along with their number.  Without sort fields, records
+// keep the order of models, except for CursorPagination which always orders
+// by id.
+//
+// Comparisons follow pointers, compare times chronologically, and numbers and
+// strings by value.  Records missing an attribute, e.g. a nil pointer or a nil
+// to-one relationship on the way to it, come first when sorting by it in
+// ascending order, and only meet FilterNe conditions on it.
+//
+// Pages requested with CursorPagination compare records as they are
+// marshaled into their cursors, see EncodeCursor.  A Page with a
+// negative Offset or a Limit that is not positive, which Paginator.Parse
+// never returns, results in an error wrapping ErrInvalidPage.
+func (q *Query) Apply(models interface{}) (*QueryResult, error) {
+	if q.Page != nil {
+		if err := q.Page.check(); err != nil {
+			return nil, err
+		}
+	}
+
+	values, err := convertToSliceInterface(&models)
+	if err != nil {
+		return nil, err
+	}
+
+	cursors := q.Page != nil && q.Page.strategy == CursorPagination
+
+	var records []queryRecord
+	for _, model := range values {
+		if !q.matches(reflect.ValueOf(model)) {
+			continue
+		}
+
+		record := queryRecord{model: model, key: reflect.ValueOf(model)}
+		if cursors {
+			if record.key, err = q.cursorKey(model); err != nil {
+				return nil, err
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	if len(q.Sort) > 0 || cursors {
+		sort.SliceStable(records, func(i, j int) bool {
+			return q.compare(records[i].key, records[j].key) < 0
+		})
+	}
+
+	result := &QueryResult{Total: len(records)}
+
+	switch {
+	case q.Page == nil:
+	case cursors:
+		if records, result.More, err = q.cursorPage(records); err != nil {
+			return nil, err
+		}
+	default:
+		if q.Page.Offset >= len(records) {
+			records = nil
+		} else {
+			records = records[q.Page.Offset:]
+		}
+		if len(records) > q.Page.Limit {
+			records = records[:q.Page.Limit]
+		}
+	}
+
+	result.Models = make([]interface{}, len(records))
+	for i, record := range records {
+		result.Models[i] = record.model
+	}
+
+	return result, nil
+}
+
+// Paginate adds the pagination links and meta of the page selected by Apply
+// to payload, holding the marshaled Models of result, with Page.Paginate.
+// With CursorPagination, the links are those of Page.PaginateCursors, with
+// cursors built from the Models of result and the sort fields, so that they
+// do not depend on the fieldsets of payload.  Nothing is added without a
+// Page, and an invalid one results in an error wrapping ErrInvalidPage.
+func (q *Query) Paginate(payload *ManyPayload, u *url.URL, result *QueryResult) error {
+	if q.Page == nil {
+		return nil
+	}
+
+	if err := q.Page.Paginate(payload, u, result.Total); err != nil {
+		return err
+	}
+
+	if q.Page.strategy == CursorPagination {
+		var first, last string
+		if len(result.Models) > 0 {
+			var err error
+			if first, err = EncodeCursor(result.Models[0], q.sortAttributes()...); err != nil {
+				return err
+			}
+			if last, err = EncodeCursor(result.Models[len(result.Models)-1],
+				q.sortAttributes()...); err != nil {
+				return err
+			}
+		}
+
+		q.Page.addCursorLinks(payload, u, result.More, first, last)
+	}
+
+	return nil
+}
+
+// queryRecord is a record selected by Query.Apply, along with the value it is
+// ordered by.
+type queryRecord struct {
+	model interface{}
+	// key is the model itself, or its values as read back from its cursor
+	key reflect.Value
+}
+
+// cursorKey returns a model holding the sort attributes and the id of model
+// as read back from its cursor.
+func (q *Query) cursorKey(model interface{}) (reflect.Value, error) {
+	cursor, err := EncodeCursor(model, q.sortAttributes()...)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	key := reflect.New(reflect.TypeOf(model).Elem())
+	if err := DecodeCursor(cursor, key.Interface(), q.sortAttributes()...); err != nil {
+		return reflect.Value{}, err
+	}
+
+	return key, nil
+}
+
+// cursorPage returns the records, in order, of the page following or
+// preceding its cursor, and whether there are more past it.
+func (q *Query) cursorPage(records []queryRecord) ([]queryRecord, bool, error) {
+	cursor, backward := q.Page.After, false
+	if q.Page.Before != "" {
+		cursor, backward = q.Page.Before, true
+	}
+
+	start, end := 0, len(records)
+	if cursor != "" && len(records) > 0 {
+		key := reflect.New(records[0].key.Type().Elem())
+		if err := DecodeCursor(cursor, key.Interface(), q.sortAttributes()...); err != nil {
+			return nil, false, err
+		}
+
+		if backward {
+			end = sort.Search(len(records), func(i int) bool {
+				return q.compare(records[i].key, key) >= 0
+			})
+		} else {
+			start = sort.Search(len(records), func(i int) bool {
+				return q.compare(records[i].key, key) > 0
+			})
+		}
+	}
+
+	if backward {
+		if end-start > q.Page.Limit {
+			start = end - q.Page.Limit
+		}
+
+		return records[start:end], start > 0, nil
+	}
+
+	if end-start > q.Page.Limit {
+		end = start + q.Page.Limit
+	}
+
+	return records[start:end], end < len(records), nil
+}
+
+// matches reports whether model meets every filter of the query.
+func (q *Query) matches(model reflect.Value) bool {
+	for _, filter := range q.Filters {
+		if !filterMatches(filter, fieldValue(model, filter.Name)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compare orders the models a and b by the sort fields of the query, then by
+// id.
+func (q *Query) compare(a, b reflect.Value) int {
+	for _, field := range q.Sort {
+		c := compareValues(fieldValue(a, field.Name), fieldValue(b, field.Name))
+		if field.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	return compareValues(primaryValue(a), primaryValue(b))
+}
+
+// filterMatches reports whether v, the value of the attribute of a record, or
+// an invalid Value if it has none, meets filter.
+func filterMatches(filter Filter, v reflect.Value) bool {
+	v = dereference(v)
+	value := reflect.ValueOf(filter.Value)
+
+	switch filter.Operator {
+	case FilterEq:
+		return v.IsValid() && equalValues(v, value)
+	case FilterNe:
+		return !v.IsValid() || !equalValues(v, value)
+	case FilterGt:
+		return v.IsValid() && compareValues(v, value) > 0
+	case FilterLt:
+		return v.IsValid() && compareValues(v, value) < 0
+	case FilterIn:
+		for i := 0; v.IsValid() && i < value.Len(); i++ {
+			if equalValues(v, value.Index(i)) {
+				return true
+			}
+		}
+	case FilterContains:
+		if v.Kind() == reflect.String {
+			return strings.Contains(v.String(), value.String())
+		}
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			for i := 0; i < v.Len(); i++ {
+				if equalValues(v.Index(i), value) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// fieldValue returns the field of model holding the attribute at path,
+// following to-one relationships for dot-separated paths as ParseSort does,
+// or an invalid Value if there is no such attribute or a relationship along
+// the way is nil.
+func fieldValue(model reflect.Value, path string) reflect.Value {
+	for {
+		model = dereference(model)
+		if model.Kind() != reflect.Struct {
+			return reflect.Value{}
+		}
+
+		schema, err := schemaOf(model.Type())
+		if err != nil {
+			return reflect.Value{}
+		}
+
+		dot := strings.Index(path, ".")
+		if dot < 0 {
+			field, ok := schema.attributes[path]
+			if !ok {
+				return reflect.Value{}
+			}
+
+			return model.Field(field.index)
+		}
+
+		field, ok := schema.relations[path[:dot]]
+		if !ok || field.toMany {
+			return reflect.Value{}
+		}
+
+		model, path = model.Field(field.index), path[dot+1:]
+	}
+}
+
+// primaryValue returns the primary field of model, or an invalid Value if it
+// has none.
+func primaryValue(model reflect.Value) reflect.Value {
+	model = dereference(model)
+	if model.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+
+	schema, err := schemaOf(model.Type())
+	if err != nil || schema.primary == nil {
+		return reflect.Value{}
+	}
+
+	return model.Field(schema.primary.index)
+}
+
+// dereference follows the pointers and interfaces holding v, returning an
+// invalid Value for a nil one.
+func dereference(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
+
+// compareValues orders a and b, which come first when invalid or nil.  Times
+// are compared chronologically, numbers, strings and booleans by value, and
+// other values by their formatting, so that the order is at least stable.
+func compareValues(a, b reflect.Value) int {
+	a, b = dereference(a), dereference(b)
+	switch {
+	case !a.IsValid() && !b.IsValid():
+		return 0
+	case !a.IsValid():
+		return -1
+	case !b.IsValid():
+		return 1
+	}
+
+	timeType := reflect.TypeOf(time.Time{})
+	if a.Type() == timeType && b.Type() == timeType {
+		at, bt := a.Interface().(time.Time), b.Interface().(time.Time)
+		switch {
+		case at.Before(bt):
+			return -1
+		case at.After(bt):
+			return 1
+		}
+
+		return 0
+	}
+
+	switch {
+	case isInt(a) && isInt(b):
+		return compareOrdered(a.Int() < b.Int(), a.Int() > b.Int())
+	case isUint(a) && isUint(b):
+		return compareOrdered(a.Uint() < b.Uint(), a.Uint() > b.Uint())
+	case isFloat(a) && isFloat(b):
+		return compareOrdered(a.Float() < b.Float(), a.Float() > b.Float())
+	case a.Kind() == reflect.String && b.Kind() == reflect.String:
+		return compareOrdered(a.String() < b.String(), a.String() > b.String())
+	case a.Kind() == reflect.Bool && b.Kind() == reflect.Bool:
+		return compareOrdered(!a.Bool() && b.Bool(), a.Bool() && !b.Bool())
+	}
+
+	as, bs := fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface())
+
+	return compareOrdered(as < bs, as > bs)
+}
+
+// equalValues reports whether a and b are equal, comparing times with
+// time.Time.Equal.
+func equalValues(a, b reflect.Value) bool {
+	a, b = dereference(a), dereference(b)
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+
+	if isOrdered(a) && isOrdered(b) {
+		return compareValues(a, b) == 0
+	}
+
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+func compareOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+
+	return 0
+}
+
+func isInt(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+
+	return false
+}
+
+func isUint(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+
+	return false
+}
+
+func isFloat(v reflect.Value) bool {
+	return v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64
+}
@@ -0,0 +1,323 @@
+package jsonapi
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func queryBlogs() []*Blog {
+	created := time.Unix(1500000000, 0)
+
+	return []*Blog{
+		{ID: 1, Title: "Go", ViewCount: 5, CreatedAt: created.Add(3 * time.Hour)},
+		{ID: 2, Title: "Rust", ViewCount: 1, CreatedAt: created.Add(1 * time.Hour)},
+		{ID: 3, Title: "C", ViewCount: 5, CreatedAt: created.Add(5 * time.Hour)},
+		{ID: 4, Title: "Zig", ViewCount: 8, CreatedAt: created.Add(2 * time.Hour)},
+		{ID: 5, Title: "Odin", ViewCount: 3, CreatedAt: created.Add(4 * time.Hour)},
+	}
+}
+
+func resultIDs(result *QueryResult) []int {
+	ids := make([]int, len(result.Models))
+	for i, model := range result.Models {
+		ids[i] = model.(*Blog).ID
+	}
+
+	return ids
+}
+
+func TestQueryApply(t *testing.T) {
+	paginator := &Paginator{DefaultSize: 2}
+
+	for rawQuery, expected := range map[string]struct {
+		ids   []int
+		total int
+	}{
+		"":                                          {[]int{1, 2}, 5},
+		"sort=-view_count,title":                    {[]int{4, 3}, 5},
+		"sort=-view_count,title&page[number]=2":     {[]int{1, 5}, 5},
+		"sort=created_at&page[size]=10":             {[]int{2, 4, 1, 5, 3}, 5},
+		"filter[view_count]=5&sort=-title":          {[]int{1, 3}, 2},
+		"filter[view_count][gt]=2&page[number]=2":   {[]int{4, 5}, 4},
+		"filter[title][in]=Go,Zig,Java":             {[]int{1, 4}, 2},
+		"filter[title][contains]=i&sort=title":      {[]int{5, 4}, 2},
+		"filter[created_at][lt]=1500009000":         {[]int{2, 4}, 2},
+		"filter[title][ne]=Go&filter[view_count]=5": {[]int{3}, 1},
+		"page[number]=4":                            {[]int{}, 5},
+	} {
+		values, _ := url.ParseQuery(rawQuery)
+
+		query, err := ParseQuery(values, new(Blog), paginator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := query.Apply(queryBlogs())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if e, a := expected.ids, resultIDs(result); !reflect.DeepEqual(e, a) {
+			t.Fatalf("Was expecting blogs %v for %q, got %v", e, rawQuery, a)
+		}
+		if result.Total != expected.total {
+			t.Fatalf("Was expecting a total of %d for %q, got %d", expected.total, rawQuery, result.Total)
+		}
+	}
+}
+
+func TestQueryApplyRelatedSort(t *testing.T) {
+	blogs := []*Blog{
+		{ID: 1, CurrentPost: &Post{ID: 1, Title: "B"}},
+		{ID: 2},
+		{ID: 3, CurrentPost: &Post{ID: 2, Title: "A"}},
+	}
+
+	query := &Query{Sort: []SortField{{Name: "current_post.title"}}}
+
+	result, err := query.Apply(blogs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Blogs without a current post come first
+	if e, a := []int{2, 3, 1}, resultIDs(result); !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting blogs %v, got %v", e, a)
+	}
+
+	messages := []*Message{
+		{ID: 1, Pinned: &Video{ID: 1, URL: "https://example.com/b.mp4"}},
+		{ID: 2, Pinned: &Photo{ID: 1, URL: "https://example.com/c.png"}},
+		{ID: 3, Pinned: &Photo{ID: 2, URL: "https://example.com/a.png"}},
+	}
+
+	query = &Query{Sort: []SortField{{Name: "pinned.url", Desc: true}}}
+
+	result, err = query.Apply(messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []int{}
+	for _, model := range result.Models {
+		ids = append(ids, model.(*Message).ID)
+	}
+	if e, a := []int{2, 1, 3}, ids; !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting messages %v, got %v", e, a)
+	}
+}
+
+func TestQueryApplyPointerAttributes(t *testing.T) {
+	ford, fiat := "Ford", "Fiat"
+	year := uint(1964)
+	cars := []*Car{
+		{ID: &ford, Make: &ford, Year: &year},
+		{ID: &fiat, Make: &fiat},
+		{ID: new(string)},
+	}
+
+	for rawQuery, expected := range map[string]int{
+		"filter[make]=Ford":               1,
+		"filter[make][ne]=Ford":           2,
+		"filter[make][contains]=F":        2,
+		"filter[year][gt]=1900":           1,
+		"filter[year][lt]=1900":           0,
+		"filter[year][in]=1964,1965":      1,
+		"filter[make][in]=Fiat&sort=make": 1,
+	} {
+		values, _ := url.ParseQuery(rawQuery)
+
+		query, err := ParseQuery(values, new(Car), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := query.Apply(cars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Total != expected || len(result.Models) != expected {
+			t.Fatalf("Was expecting %d cars for %q, got %d", expected, rawQuery, result.Total)
+		}
+	}
+}
+
+func TestQueryPaginateOffsets(t *testing.T) {
+	paginator := &Paginator{Strategy: OffsetPagination, DefaultSize: 2}
+	values := url.Values{"filter[view_count][gt]": {"2"}, QueryParamPageOffset: {"2"}}
+
+	query, err := ParseQuery(values, new(Blog), paginator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := query.Apply(queryBlogs())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := MarshalMany(result.Models)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := &url.URL{Scheme: "https", Host: "example.com", Path: "/blogs", RawQuery: values.Encode()}
+	if err := query.Paginate(payload, u, result); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := "https://example.com/blogs?filter%5Bview_count%5D%5Bgt%5D=2&page%5Blimit%5D=2&page%5Boffset%5D=0",
+		(*payload.Links)[KeyPreviousPage]; e != a {
+		t.Fatalf("Was expecting the prev link %s, got %v", e, a)
+	}
+	if _, ok := (*payload.Links)[KeyNextPage]; ok {
+		t.Fatal("Was expecting no next link on the last page")
+	}
+	if e, a := 4, (*payload.Meta)[KeyTotalRecords]; e != a {
+		t.Fatalf("Was expecting a total of %d, got %v", e, a)
+	}
+}
+
+func TestQueryPaginateCursors(t *testing.T) {
+	paginator := &Paginator{Strategy: CursorPagination, DefaultSize: 2}
+
+	// fetch returns the ids of the page requested with link, and its links
+	fetch := func(link string) ([]int, *Links) {
+		u, err := url.Parse(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		query, err := ParseQuery(u.Query(), new(Blog), paginator)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := query.Apply(queryBlogs())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		payload, err := MarshalMany(result.Models)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := query.Paginate(payload, u, result); err != nil {
+			t.Fatal(err)
+		}
+
+		return resultIDs(result), payload.Links
+	}
+
+	// Follow the next links to the last page, then the prev links back
+	ids, links := fetch("https://example.com/blogs?sort=-view_count")
+	pages := [][]int{ids}
+	for _, key := range []string{KeyNextPage, KeyPreviousPage} {
+		for {
+			link, ok := (*links)[key].(string)
+			if !ok {
+				break
+			}
+
+			ids, links = fetch(link)
+			pages = append(pages, ids)
+		}
+	}
+
+	// Blogs with as many views are ordered by id
+	expected := [][]int{{4, 1}, {3, 5}, {2}, {3, 5}, {4, 1}}
+	if !reflect.DeepEqual(expected, pages) {
+		t.Fatalf("Was expecting pages %v, got %v", expected, pages)
+	}
+}
+
+func TestQueryApplyInvalidPage(t *testing.T) {
+	for _, page := range []*Page{
+		{Offset: -6, Limit: 10},
+		{Limit: 0},
+		{Limit: -1, strategy: CursorPagination},
+	} {
+		query := &Query{Page: page}
+		if _, err := query.Apply(queryBlogs()); !errors.Is(err, ErrInvalidPage) {
+			t.Fatalf("Was expecting ErrInvalidPage for %+v, got %v", page, err)
+		}
+	}
+
+	// An overflowing page number is rejected before reaching Apply
+	values := url.Values{QueryParamPageNumber: {"1844674407370955162"}, QueryParamPageSize: {"10"}}
+	if _, err := ParseQuery(values, new(Blog), &Paginator{}); err == nil {
+		t.Fatal("Was expecting the page number to be rejected")
+	}
+}
+
+func TestQueryPaginateCursorsSparseFieldsets(t *testing.T) {
+	values := url.Values{QueryParamSort: {"title"}, QueryParamPageSize: {"2"}}
+
+	query, err := ParseQuery(values, new(Blog), &Paginator{Strategy: CursorPagination})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := query.Apply(queryBlogs())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The cursors are built from the models, not from the payload
+	payload, err := MarshalMany(result.Models, WithFields(map[string][]string{"blogs": {}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse("/blogs?sort=title")
+	if err := query.Paginate(payload, u, result); err != nil {
+		t.Fatal(err)
+	}
+
+	last, _ := EncodeCursor(result.Models[1], "title")
+	if e, a := "/blogs?page%5Bafter%5D="+last+"&page%5Bsize%5D=2&sort=title", (*payload.Links)[KeyNextPage]; e != a {
+		t.Fatalf("Was expecting the next link %v, got %v", e, a)
+	}
+	if e, a := 5, (*payload.Meta)[KeyTotalRecords]; e != a {
+		t.Fatalf("Was expecting a total of %d, got %v", e, a)
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	values := url.Values{
+		QueryParamSort:       {"unknown"},
+		"filter[view_count]": {"many"},
+		QueryParamPageNumber: {"0"},
+	}
+
+	_, err := ParseQuery(values, new(Blog), &Paginator{})
+
+	queryErrors, ok := err.(QueryErrors)
+	if !ok {
+		t.Fatalf("Was expecting QueryErrors, got %v", err)
+	}
+
+	parameters := []string{}
+	for _, errorObject := range queryErrors.ErrorObjects() {
+		parameters = append(parameters, errorObject.Source.Parameter)
+	}
+	if e, a := []string{QueryParamSort, "filter[view_count]", QueryParamPageNumber}, parameters; !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting errors for %v, got %v", e, a)
+	}
+
+	cursor, err := EncodeCursor(&Blog{ID: 1, Title: "Go"}, "title")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, values := range []url.Values{
+		{QueryParamSort: {"current_post.title"}},
+		{QueryParamSort: {"view_count"}, QueryParamPageAfter: {cursor}},
+	} {
+		_, err := ParseQuery(values, new(Blog), &Paginator{Strategy: CursorPagination})
+		if _, ok := err.(QueryErrors); !ok {
+			t.Fatalf("Was expecting %v to be invalid with cursors, got %v", values, err)
+		}
+	}
+}
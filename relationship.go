@@ -0,0 +1,235 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+var (
+	// ErrUnknownRelationship is returned when a relationship name does not
+	// match the relation tags of a model.
+	ErrUnknownRelationship = errors.New("Unknown relationship")
+	// ErrExpectedRelationshipTarget is returned when UnmarshalRelationship is
+	// not given a pointer to a slice of struct pointers or to a struct pointer.
+	ErrExpectedRelationshipTarget = errors.New(
+		"target should be a pointer to a slice of struct pointers or to a struct pointer")
+	// ErrExpectedRelationshipModel is returned when MarshalRelationship is not
+	// given a non-nil pointer to a struct.
+	ErrExpectedRelationshipModel = errors.New("model should be a non-nil pointer to a struct")
+	// ErrMissingData is returned by UnmarshalRelationship when the request
+	// has no "data" member.
+	ErrMissingData = errors.New("Relationship data is missing")
+)
+
+// MarshalRelationship writes a jsonapi response for a relationship endpoint,
+// e.g. /blogs/1/relationships/posts, holding the resource linkage of the named
+// relationship of model along with its links and meta, see
+// RelationshipLinkable and RelationshipMetable.
+//
+//	{"data": [{"type": "posts", "id": "1"}, {"type": "posts", "id": "2"}]}
+//
+// model interface{} should be a non-nil pointer to a struct, otherwise
+// ErrExpectedRelationshipModel is returned.
+//
+// http://jsonapi.org/format/#fetching-relationships
+func MarshalRelationship(w io.Writer, model interface{}, relation string) error {
+	modelValue := reflect.ValueOf(model)
+	if modelValue.Kind() != reflect.Ptr || modelValue.IsNil() ||
+		modelValue.Elem().Kind() != reflect.Struct {
+		return ErrExpectedRelationshipModel
+	}
+
+	schema, err := schemaOf(modelValue.Type().Elem())
+	if err != nil {
+		return err
+	}
+
+	field, ok := schema.relations[relation]
+	if !ok {
+		return fmt.Errorf("%w: %s is not a relationship of %s",
+			ErrUnknownRelationship, relation, schema.typ)
+	}
+
+	// Marshal the relationship alone, without traversing the related records
+	opts := newMarshalOptions([]MarshalOption{
+		WithFields(map[string][]string{schema.typ: {relation}}),
+		WithInclude(),
+	})
+	included := make(map[string]*Node)
+
+	node, err := visitModelNode(model, &included, true, opts)
+	if err != nil {
+		return err
+	}
+
+	payload := node.Relationships[relation]
+	if payload == nil {
+		// An empty relationship tagged omitempty
+		if field.toMany {
+			payload = &RelationshipManyNode{Data: []*Node{}}
+		} else {
+			payload = &RelationshipOneNode{}
+		}
+	}
+
+	return json.NewEncoder(w).Encode(payload)
+}
+
+// UnmarshalRelationship reads a jsonapi request for a relationship endpoint,
+// whose data is a list of resource identifier objects, or a single one or
+// null, into target, a pointer to a slice of struct pointers or to a struct
+// pointer, e.g. to handle a POST to /blogs/1/relationships/posts,
+//
+//	posts := []*Post{}
+//	if err := jsonapi.UnmarshalRelationship(r.Body, &posts); err != nil {
+//		...
+//	}
+//
+// Each record only has its id set.  As with polymorphic relationships, the
+// slice elements, or the pointer, may be of an interface type, resolved
+// through the models registered with Register.  Resource identifiers without
+// an id, or whose type does not match the target, are rejected with an
+// *UnmarshalError, as is a request without data, wrapping ErrMissingData.
+//
+// http://jsonapi.org/format/#crud-updating-relationships
+func UnmarshalRelationship(in io.Reader, target interface{}) error {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() ||
+		!isRelationType(targetValue.Type().Elem()) {
+		return ErrExpectedRelationshipTarget
+	}
+	targetValue = targetValue.Elem()
+
+	doc := new(relationshipPayloadOrErrors)
+	if err := json.NewDecoder(in).Decode(doc); err != nil {
+		return err
+	}
+
+	if doc.Errors != nil {
+		return &ErrorsPayload{Errors: doc.Errors, Meta: doc.Meta}
+	}
+
+	if doc.Data == nil {
+		return &UnmarshalError{
+			Pointer: "/data",
+			Type:    targetValue.Type(),
+			Err:     ErrMissingData,
+		}
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(doc.Data, &data); err != nil {
+		return err
+	}
+
+	if targetValue.Kind() == reflect.Slice {
+		if _, ok := data.([]interface{}); !ok {
+			return &UnmarshalError{
+				Pointer:  "/data",
+				Type:     targetValue.Type(),
+				JSONKind: jsonKind(data),
+				Err:      ErrInvalidType,
+			}
+		}
+
+		var nodes []*Node
+		if err := json.Unmarshal(doc.Data, &nodes); err != nil {
+			return err
+		}
+
+		models := reflect.MakeSlice(targetValue.Type(), 0, len(nodes))
+		for i, n := range nodes {
+			m, err := unmarshalIdentifier(n, targetValue.Type().Elem(), fmt.Sprintf("/data/%d", i))
+			if err != nil {
+				return err
+			}
+
+			models = reflect.Append(models, m)
+		}
+
+		targetValue.Set(models)
+
+		return nil
+	}
+
+	switch data.(type) {
+	case nil:
+		targetValue.Set(reflect.Zero(targetValue.Type()))
+		return nil
+	case map[string]interface{}:
+	default:
+		return &UnmarshalError{
+			Pointer:  "/data",
+			Type:     targetValue.Type(),
+			JSONKind: jsonKind(data),
+			Err:      ErrInvalidType,
+		}
+	}
+
+	n := new(Node)
+	if err := json.Unmarshal(doc.Data, n); err != nil {
+		return err
+	}
+
+	m, err := unmarshalIdentifier(n, targetValue.Type(), "/data")
+	if err != nil {
+		return err
+	}
+
+	targetValue.Set(m)
+
+	return nil
+}
+
+// relationshipPayloadOrErrors is decoded from the request of a relationship
+// endpoint, keeping its data as is until the shape of the target is known.
+type relationshipPayloadOrErrors struct {
+	Data   json.RawMessage `json:"data"`
+	Meta   *Meta           `json:"meta"`
+	Errors []*ErrorObject  `json:"errors"`
+}
+
+// unmarshalIdentifier unmarshals the resource identifier object n, found at
+// pointer, into a new record of type recordType.
+func unmarshalIdentifier(n *Node, recordType reflect.Type, pointer string) (reflect.Value, error) {
+	if n == nil {
+		return reflect.Value{}, &UnmarshalError{
+			Pointer:  pointer,
+			Type:     recordType,
+			JSONKind: jsonKind(nil),
+			Err:      ErrInvalidType,
+		}
+	}
+
+	if n.ID == "" {
+		return reflect.Value{}, &UnmarshalError{
+			Pointer:  pointer,
+			Type:     recordType,
+			JSONKind: "object",
+			Err:      ErrMissingID,
+		}
+	}
+
+	if recordType == reflect.TypeOf(new(ResourceIdentifier)) {
+		return reflect.ValueOf(resourceIdentifier(n)), nil
+	}
+
+	m, err := newRelated(recordType, n)
+	if err != nil {
+		return reflect.Value{}, &UnmarshalError{
+			Pointer:  pointer + "/type",
+			Type:     recordType,
+			JSONKind: jsonKind(n.Type),
+			Err:      err,
+		}
+	}
+
+	if err := unmarshalNode(n, m, nil, pointer); err != nil {
+		return reflect.Value{}, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,161 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarshalRelationshipToMany(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := MarshalRelationship(out, testBlog(), "posts"); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := make(map[string]interface{})
+	if err := json.NewDecoder(out).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []interface{}{
+		map[string]interface{}{"type": "posts", "id": "1"},
+		map[string]interface{}{"type": "posts", "id": "2"},
+	}
+	if !reflect.DeepEqual(expected, resp["data"]) {
+		t.Fatalf("Was expecting the linkage %v, got %v", expected, resp["data"])
+	}
+	if resp["links"] == nil || resp["meta"] == nil {
+		t.Fatalf("Was expecting the relationship links and meta, got %v", resp)
+	}
+	if resp["included"] != nil {
+		t.Fatalf("Was not expecting included records, got %v", resp["included"])
+	}
+}
+
+func TestMarshalRelationshipToOne(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := MarshalRelationship(out, &Blog{ID: 5}, "current_post"); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := make(map[string]interface{})
+	if err := json.NewDecoder(out).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, ok := resp["data"]; !ok || data != nil {
+		t.Fatalf("Was expecting null data, got %v", resp)
+	}
+
+	err := MarshalRelationship(out, testBlog(), "authors")
+	if !errors.Is(err, ErrUnknownRelationship) {
+		t.Fatalf("Was expecting ErrUnknownRelationship, got %v", err)
+	}
+}
+
+func TestMarshalRelationshipOmitEmpty(t *testing.T) {
+	type Author struct {
+		ID    int     `jsonapi:"primary,authors"`
+		Books []*Book `jsonapi:"relation,books,omitempty"`
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalRelationship(out, &Author{ID: 1}, "books"); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := "{\"data\":[]}\n", out.String(); e != a {
+		t.Fatalf("Was expecting %q, got %q", e, a)
+	}
+}
+
+func TestMarshalRelationshipInvalidModel(t *testing.T) {
+	var blog *Blog
+
+	for _, model := range []interface{}{nil, blog, Blog{}, new(int)} {
+		out := bytes.NewBuffer(nil)
+		if err := MarshalRelationship(out, model, "posts"); err != ErrExpectedRelationshipModel {
+			t.Fatalf("Was expecting ErrExpectedRelationshipModel for %#v, got %v", model, err)
+		}
+	}
+}
+
+func TestUnmarshalRelationshipToMany(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": [{"type": "posts", "id": "1"}, {"type": "posts", "id": "2"}]
+	}`)
+
+	posts := []*Post{}
+	if err := UnmarshalRelationship(in, &posts); err != nil {
+		t.Fatal(err)
+	}
+
+	if e := []*Post{{ID: 1}, {ID: 2}}; !reflect.DeepEqual(e, posts) {
+		t.Fatalf("Was expecting %#v, got %#v", e, posts)
+	}
+}
+
+func TestUnmarshalRelationshipToOne(t *testing.T) {
+	post := &Post{ID: 3}
+	if err := UnmarshalRelationship(strings.NewReader(`{"data": null}`), &post); err != nil {
+		t.Fatal(err)
+	}
+	if post != nil {
+		t.Fatalf("Was expecting null data to clear the relationship, got %#v", post)
+	}
+
+	var attachment Attachment
+	if err := UnmarshalRelationship(strings.NewReader(`{
+		"data": {"type": "videos", "id": "2"}
+	}`), &attachment); err != nil {
+		t.Fatal(err)
+	}
+	if e := (&Video{ID: 2}); !reflect.DeepEqual(e, attachment) {
+		t.Fatalf("Was expecting %#v, got %#v", e, attachment)
+	}
+}
+
+func TestUnmarshalRelationshipErrors(t *testing.T) {
+	posts := []*Post{}
+
+	err := UnmarshalRelationship(strings.NewReader(`{"data": {"type": "posts", "id": "1"}}`), &posts)
+	if unmarshalError, ok := err.(*UnmarshalError); !ok || unmarshalError.JSONKind != "object" {
+		t.Fatalf("Was expecting an *UnmarshalError for the object, got %v", err)
+	}
+
+	err = UnmarshalRelationship(strings.NewReader(`{
+		"data": [{"type": "posts", "id": "1"}, {"type": "comments", "id": "2"}]
+	}`), &posts)
+	if unmarshalError, ok := err.(*UnmarshalError); !ok || unmarshalError.Pointer != "/data/1/type" {
+		t.Fatalf("Was expecting an *UnmarshalError for the mismatched type, got %v", err)
+	}
+
+	err = UnmarshalRelationship(strings.NewReader(`{"data": [{"type": "comments"}]}`), &posts)
+	if unmarshalError, ok := err.(*UnmarshalError); !ok || unmarshalError.Pointer != "/data/0" ||
+		!errors.Is(err, ErrMissingID) {
+		t.Fatalf("Was expecting an *UnmarshalError for the missing id, got %v", err)
+	}
+
+	post := &Post{ID: 3}
+	err = UnmarshalRelationship(strings.NewReader(`{"data": {"type": "comments", "id": "1"}}`), &post)
+	if unmarshalError, ok := err.(*UnmarshalError); !ok || unmarshalError.Pointer != "/data/type" ||
+		!errors.Is(err, ErrTypeConflict) {
+		t.Fatalf("Was expecting an *UnmarshalError for the mismatched type, got %v", err)
+	}
+
+	err = UnmarshalRelationship(strings.NewReader(`{}`), &post)
+	if unmarshalError, ok := err.(*UnmarshalError); !ok || unmarshalError.Pointer != "/data" ||
+		!errors.Is(err, ErrMissingData) {
+		t.Fatalf("Was expecting an *UnmarshalError for the missing data, got %v", err)
+	}
+	if post == nil || post.ID != 3 {
+		t.Fatalf("Was expecting the relationship to be left as is, got %#v", post)
+	}
+
+	if err := UnmarshalRelationship(strings.NewReader(`{"data": []}`), posts); err != ErrExpectedRelationshipTarget {
+		t.Fatalf("Was expecting ErrExpectedRelationshipTarget, got %v", err)
+	}
+}
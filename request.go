@@ -2,6 +2,7 @@ package jsonapi
 
 import (
 	"bytes"
+	"encoding"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -30,12 +31,81 @@ var (
 	// ErrUnsupportedPtrType is returned when the Struct field was a pointer but
 	// the JSON value was of a different type
 	ErrUnsupportedPtrType = errors.New("Pointer type in struct is not supported")
+	// ErrInvalidType is returned when the given type is incompatible with the
+	// expected type.
+	ErrInvalidType = errors.New("Invalid type provided")
+	// ErrUnregisteredType is returned when a polymorphic relationship holds a
+	// resource whose type was not registered with Register.
+	ErrUnregisteredType = errors.New("Resource type is not registered")
 )
 
+// UnmarshalError is returned when a member of a resource object could not be
+// unmarshaled into its struct field. It wraps the cause, usually one of the
+// Err* values of this package, so that it can be matched with errors.Is.
+type UnmarshalError struct {
+	// Pointer is a JSON Pointer [RFC6901] to the offending member of the
+	// payload, e.g. "/data/attributes/created_at".
+	Pointer string
+	// Field is the name of the struct field that was being unmarshaled.
+	Field string
+	// Type is the Go type that was expected.
+	Type reflect.Type
+	// JSONKind is the kind of the JSON value that was found: "string",
+	// "number", "boolean", "array", "object" or "null".
+	JSONKind string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the `Error` interface.
+func (e *UnmarshalError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %v", e.Pointer, e.Err)
+	}
+
+	return fmt.Sprintf(
+		"%s: cannot unmarshal %s into struct field %s of type %v: %v",
+		e.Pointer,
+		e.JSONKind,
+		e.Field,
+		e.Type,
+		e.Err,
+	)
+}
+
+// Unwrap returns the underlying error.
+func (e *UnmarshalError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorObject converts the error into a 422 Unprocessable Entity error object,
+// or a 409 Conflict one for ErrTypeConflict, whose source points at the
+// offending member.
+func (e *UnmarshalError) ErrorObject() *ErrorObject {
+	if errors.Is(e.Err, ErrTypeConflict) {
+		return &ErrorObject{
+			Status: "409",
+			Title:  "Conflict",
+			Detail: e.Err.Error(),
+			Source: &ErrorSource{Pointer: e.Pointer},
+		}
+	}
+
+	return &ErrorObject{
+		Status: "422",
+		Title:  "Unprocessable Entity",
+		Detail: e.Err.Error(),
+		Source: &ErrorSource{Pointer: e.Pointer},
+	}
+}
+
 // UnmarshalPayload converts an io into a struct instance using jsonapi tags on
 // struct fields. This method supports single request payloads only, at the
 // moment. Bulk creates and updates are not supported yet.
 //
+// If the payload is an errors document rather than a data document, the
+// returned error will be an *ErrorsPayload holding the decoded error objects.
+//
 // Will Unmarshal embedded and sideloaded payloads.  The latter is only possible if the
 // object graph is complete.  That is, in the "relationships" data there are type and id,
 // keys that correspond to records in the "included" array.
@@ -65,442 +135,448 @@ var (
 // Visit https://github.com/google/jsonapi#create for more info.
 //
 // model interface{} should be a pointer to a struct.
-func UnmarshalPayload(in io.Reader, model interface{}) error {
-	payload := new(OnePayload)
+func UnmarshalPayload(in io.Reader, model interface{}, opts ...UnmarshalOption) error {
+	_, err := UnmarshalPayloadWithMeta(in, model, opts...)
+	return err
+}
 
-	if err := json.NewDecoder(in).Decode(payload); err != nil {
-		return err
+// UnmarshalPayloadWithMeta does the same as UnmarshalPayload except it also
+// returns the top-level "meta" object of the payload, which will be nil if the
+// payload had none.
+func UnmarshalPayloadWithMeta(in io.Reader, model interface{},
+	opts ...UnmarshalOption) (*Meta, error) {
+	payload, err := unmarshalOnePayload(in, model, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	if payload.Included != nil {
-		includedMap := make(map[string]*Node)
-		for _, included := range payload.Included {
-			key := fmt.Sprintf("%s,%s", included.Type, included.ID)
-			includedMap[key] = included
-		}
-
-		return unmarshalNode(payload.Data, reflect.ValueOf(model), &includedMap)
-	}
-	return unmarshalNode(payload.Data, reflect.ValueOf(model), nil)
+	return payload.Meta, nil
 }
 
-// UnmarshalManyPayload converts an io into a set of struct instances using
-// jsonapi tags on the type's struct fields.
-func UnmarshalManyPayload(in io.Reader, t reflect.Type) ([]interface{}, error) {
-	payload := new(ManyPayload)
+// unmarshalOnePayload decodes a payload with one primary data into model,
+// returning the decoded payload.
+func unmarshalOnePayload(in io.Reader, model interface{},
+	opts []UnmarshalOption) (*OnePayload, error) {
+	options := newUnmarshalOptions(opts)
 
-	if err := json.NewDecoder(in).Decode(payload); err != nil {
+	in, err := validateInput(in, options)
+	if err != nil {
 		return nil, err
 	}
 
-	if payload.Included != nil {
-		includedMap := make(map[string]*Node)
-		for _, included := range payload.Included {
-			key := fmt.Sprintf("%s,%s", included.Type, included.ID)
-			includedMap[key] = included
-		}
+	doc := new(onePayloadOrErrors)
 
-		var models []interface{}
-		for _, data := range payload.Data {
-			model := reflect.New(t.Elem())
-			err := unmarshalNode(data, model, &includedMap)
-			if err != nil {
-				return nil, err
-			}
-			models = append(models, model.Interface())
-		}
+	if err := json.NewDecoder(in).Decode(doc); err != nil {
+		return nil, err
+	}
 
-		return models, nil
+	if doc.Errors != nil {
+		return nil, &ErrorsPayload{Errors: doc.Errors, Meta: doc.Meta}
 	}
 
-	var models []interface{}
+	payload := &doc.OnePayload
+	included := newIncludedNodes(payload.Included)
 
-	for _, data := range payload.Data {
-		model := reflect.New(t.Elem())
-		err := unmarshalNode(data, model, nil)
-		if err != nil {
-			return nil, err
-		}
-		models = append(models, model.Interface())
+	checker := newDocumentChecker(options, payload.Included, included)
+	if checker != nil {
+		checker.checkPrimary(payload.Data, reflect.TypeOf(model), "/data")
 	}
 
-	return models, nil
-}
-
-func unmarshalNode(data *Node, model reflect.Value, included *map[string]*Node) (err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("data is not a jsonapi representation of '%v'", model.Type())
-		}
-	}()
+	err = unmarshalNode(payload.Data, reflect.ValueOf(model), included, "/data")
+	if checker != nil {
+		err = checker.result(err)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	modelValue := model.Elem()
-	modelType := model.Type().Elem()
+	return payload, nil
+}
 
-	var er error
+// UnmarshalManyPayload converts an io into a set of struct instances using
+// jsonapi tags on the type's struct fields.
+func UnmarshalManyPayload(in io.Reader, t reflect.Type,
+	opts ...UnmarshalOption) ([]interface{}, error) {
+	models, _, err := UnmarshalManyPayloadWithMeta(in, t, opts...)
+	return models, err
+}
 
-	for i := 0; i < modelValue.NumField(); i++ {
-		fieldType := modelType.Field(i)
-		tag := fieldType.Tag.Get("jsonapi")
-		if tag == "" {
-			continue
-		}
+// UnmarshalManyPayloadWithMeta does the same as UnmarshalManyPayload except it
+// also returns the top-level "meta" object of the payload, which will be nil if
+// the payload had none.
+func UnmarshalManyPayloadWithMeta(in io.Reader, t reflect.Type,
+	opts ...UnmarshalOption) ([]interface{}, *Meta, error) {
+	return unmarshalManyPayload(in, func(data *Node, pointer string) (reflect.Value, error) {
+		return reflect.New(t.Elem()), nil
+	}, opts)
+}
 
-		fieldValue := modelValue.Field(i)
+// UnmarshalMixedManyPayload converts an io into a set of struct instances of
+// different types, e.g. the users and groups of a search result.  The type of
+// each instance is the model registered with Register for the "type" of its
+// resource object; a resource object of a type that was not registered
+// results in an *UnmarshalError wrapping ErrUnregisteredType.
+func UnmarshalMixedManyPayload(in io.Reader, opts ...UnmarshalOption) ([]interface{}, error) {
+	models, _, err := UnmarshalMixedManyPayloadWithMeta(in, opts...)
+	return models, err
+}
 
-		args := strings.Split(tag, ",")
+// UnmarshalMixedManyPayloadWithMeta does the same as UnmarshalMixedManyPayload
+// except it also returns the top-level "meta" object of the payload, which will
+// be nil if the payload had none.
+func UnmarshalMixedManyPayloadWithMeta(in io.Reader,
+	opts ...UnmarshalOption) ([]interface{}, *Meta, error) {
+	return unmarshalManyPayload(in, newRegisteredModel, opts)
+}
 
-		if len(args) < 1 {
-			er = ErrBadJSONAPIStructTag
-			break
+// newRegisteredModel allocates the model registered with Register for the
+// type of the resource object data, found at pointer.
+func newRegisteredModel(data *Node, pointer string) (reflect.Value, error) {
+	if data == nil {
+		return reflect.Value{}, &UnmarshalError{
+			Pointer:  pointer,
+			JSONKind: jsonKind(nil),
+			Err:      ErrInvalidType,
 		}
+	}
 
-		annotation := args[0]
-
-		if (annotation == annotationClientID && len(args) != 1) ||
-			(annotation != annotationClientID && len(args) < 2) {
-			er = ErrBadJSONAPIStructTag
-			break
+	modelType, ok := registeredType(data.Type)
+	if !ok {
+		return reflect.Value{}, &UnmarshalError{
+			Pointer:  pointer + "/type",
+			JSONKind: jsonKind(data.Type),
+			Err:      ErrUnregisteredType,
 		}
+	}
 
-		if annotation == "primary" {
-			if data.ID == "" {
-				continue
-			}
-
-			// Check the JSON API Type
-			if data.Type != args[1] {
-				er = fmt.Errorf(
-					"Trying to Unmarshal an object of type %#v, but %#v does not match",
-					data.Type,
-					args[1],
-				)
-				break
-			}
+	return reflect.New(modelType), nil
+}
 
-			// ID will have to be transmitted as astring per the JSON API spec
-			v := reflect.ValueOf(data.ID)
+// unmarshalManyPayload decodes a payload with many primary data, allocating
+// the model of each resource object with newModel.
+func unmarshalManyPayload(in io.Reader,
+	newModel func(data *Node, pointer string) (reflect.Value, error),
+	opts []UnmarshalOption) ([]interface{}, *Meta, error) {
+	options := newUnmarshalOptions(opts)
 
-			// Deal with PTRS
-			var kind reflect.Kind
-			if fieldValue.Kind() == reflect.Ptr {
-				kind = fieldType.Type.Elem().Kind()
-			} else {
-				kind = fieldType.Type.Kind()
-			}
+	in, err := validateInput(in, options)
+	if err != nil {
+		return nil, nil, err
+	}
 
-			// Handle String case
-			if kind == reflect.String {
-				assign(fieldValue, v)
-				continue
-			}
+	doc := new(manyPayloadOrErrors)
 
-			// Value was not a string... only other supported type was a numeric,
-			// which would have been sent as a float value.
-			floatValue, err := strconv.ParseFloat(data.ID, 64)
-			if err != nil {
-				// Could not convert the value in the "id" attr to a float
-				er = ErrBadJSONAPIID
-				break
-			}
+	if err := json.NewDecoder(in).Decode(doc); err != nil {
+		return nil, nil, err
+	}
 
-			// Convert the numeric float to one of the supported ID numeric types
-			// (int[8,16,32,64] or uint[8,16,32,64])
-			var idValue reflect.Value
-			switch kind {
-			case reflect.Int:
-				n := int(floatValue)
-				idValue = reflect.ValueOf(&n)
-			case reflect.Int8:
-				n := int8(floatValue)
-				idValue = reflect.ValueOf(&n)
-			case reflect.Int16:
-				n := int16(floatValue)
-				idValue = reflect.ValueOf(&n)
-			case reflect.Int32:
-				n := int32(floatValue)
-				idValue = reflect.ValueOf(&n)
-			case reflect.Int64:
-				n := int64(floatValue)
-				idValue = reflect.ValueOf(&n)
-			case reflect.Uint:
-				n := uint(floatValue)
-				idValue = reflect.ValueOf(&n)
-			case reflect.Uint8:
-				n := uint8(floatValue)
-				idValue = reflect.ValueOf(&n)
-			case reflect.Uint16:
-				n := uint16(floatValue)
-				idValue = reflect.ValueOf(&n)
-			case reflect.Uint32:
-				n := uint32(floatValue)
-				idValue = reflect.ValueOf(&n)
-			case reflect.Uint64:
-				n := uint64(floatValue)
-				idValue = reflect.ValueOf(&n)
-			default:
-				// We had a JSON float (numeric), but our field was not one of the
-				// allowed numeric types
-				er = ErrBadJSONAPIID
-				break
-			}
+	if doc.Errors != nil {
+		return nil, nil, &ErrorsPayload{Errors: doc.Errors, Meta: doc.Meta}
+	}
 
-			assign(fieldValue, idValue)
-		} else if annotation == annotationClientID {
-			if data.ClientID == "" {
-				continue
-			}
+	payload := &doc.ManyPayload
+	included := newIncludedNodes(payload.Included)
+	checker := newDocumentChecker(options, payload.Included, included)
 
-			fieldValue.Set(reflect.ValueOf(data.ClientID))
-		} else if annotation == "attr" {
-			attributes := data.Attributes
-			if attributes == nil || len(data.Attributes) == 0 {
-				continue
-			}
+	var models []interface{}
 
-			var iso8601 bool
+	for i, data := range payload.Data {
+		pointer := fmt.Sprintf("/data/%d", i)
 
-			if len(args) > 2 {
-				for _, arg := range args[2:] {
-					if arg == "iso8601" {
-						iso8601 = true
-					}
-				}
+		model, err := newModel(data, pointer)
+		if err == nil {
+			if checker != nil {
+				checker.checkPrimary(data, model.Type(), pointer)
 			}
 
-			val := attributes[args[1]]
-
-			// continue if the attribute was not included in the request
-			if val == nil {
+			err = unmarshalNode(data, model, included, pointer)
+		}
+		if err != nil {
+			// Carry on collecting violations
+			if checker != nil && checker.add(err) {
 				continue
 			}
+			return nil, nil, err
+		}
 
-			v := reflect.ValueOf(val)
-
-			// Handle field of type time.Time
-			if fieldValue.Type() == reflect.TypeOf(time.Time{}) {
-				if iso8601 {
-					var tm string
-					if v.Kind() == reflect.String {
-						tm = v.Interface().(string)
-					} else {
-						er = ErrInvalidISO8601
-						break
-					}
+		models = append(models, model.Interface())
+	}
 
-					t, err := time.Parse(iso8601TimeFormat, tm)
-					if err != nil {
-						er = ErrInvalidISO8601
-						break
-					}
+	if checker != nil {
+		if err := checker.result(nil); err != nil {
+			return nil, nil, err
+		}
+	}
 
-					fieldValue.Set(reflect.ValueOf(t))
+	return models, payload.Meta, nil
+}
 
-					continue
-				}
+// onePayloadOrErrors is decoded in place of a OnePayload so that an errors
+// document can be told apart from a document without primary data.
+type onePayloadOrErrors struct {
+	OnePayload
+	Errors []*ErrorObject `json:"errors"`
+}
 
-				var at int64
+// manyPayloadOrErrors is decoded in place of a ManyPayload so that an errors
+// document can be told apart from a document with empty primary data.
+type manyPayloadOrErrors struct {
+	ManyPayload
+	Errors []*ErrorObject `json:"errors"`
+}
 
-				if v.Kind() == reflect.Float64 {
-					at = int64(v.Interface().(float64))
-				} else if v.Kind() == reflect.Int {
-					at = v.Int()
-				} else {
-					er = ErrInvalidTime
-					break
-				}
+// includedNodes indexes the resource objects of a payload's "included" array
+// by type and id.
+type includedNodes map[string]*includedNode
 
-				t := time.Unix(at, 0)
+// includedNode is a resource object from the "included" array along with the
+// JSON pointer to where it was found in the payload.
+type includedNode struct {
+	*Node
+	pointer string
+}
 
-				fieldValue.Set(reflect.ValueOf(t))
+func newIncludedNodes(nodes []*Node) includedNodes {
+	if nodes == nil {
+		return nil
+	}
 
-				continue
-			}
+	included := make(includedNodes, len(nodes))
+	for i, n := range nodes {
+		key := fmt.Sprintf("%s,%s", n.Type, n.ID)
+		included[key] = &includedNode{Node: n, pointer: fmt.Sprintf("/included/%d", i)}
+	}
 
-			if fieldValue.Type() == reflect.TypeOf([]string(nil)) {
-				values := make([]string, v.Len())
-				for i := 0; i < v.Len(); i++ {
-					values[i] = v.Index(i).Interface().(string)
-				}
+	return included
+}
 
-				fieldValue.Set(reflect.ValueOf(values))
+func unmarshalNode(data *Node, model reflect.Value, included includedNodes,
+	pointer string) (err error) {
+	// member is the JSON pointer of the member being unmarshaled, used to
+	// report where a panic happened
+	member := pointer
 
-				continue
+	defer func() {
+		if r := recover(); r != nil {
+			err = &UnmarshalError{
+				Pointer: member,
+				Type:    model.Type(),
+				Err: fmt.Errorf(
+					"data is not a jsonapi representation of '%v'",
+					model.Type(),
+				),
 			}
+		}
+	}()
 
-			if fieldValue.Type() == reflect.TypeOf(new(time.Time)) {
-				if iso8601 {
-					var tm string
-					if v.Kind() == reflect.String {
-						tm = v.Interface().(string)
-					} else {
-						er = ErrInvalidISO8601
-						break
-					}
+	if data == nil {
+		return &UnmarshalError{
+			Pointer:  pointer,
+			Type:     model.Type(),
+			JSONKind: jsonKind(nil),
+			Err: fmt.Errorf(
+				"data is not a jsonapi representation of '%v'",
+				model.Type(),
+			),
+		}
+	}
 
-					v, err := time.Parse(iso8601TimeFormat, tm)
-					if err != nil {
-						er = ErrInvalidISO8601
-						break
-					}
+	modelValue := model.Elem()
 
-					t := &v
+	schema, err := schemaOf(modelValue.Type())
+	if err != nil {
+		return err
+	}
 
-					fieldValue.Set(reflect.ValueOf(t))
+	for _, field := range schema.fields {
+		fieldValue := modelValue.Field(field.index)
+		fieldType := field.structField
+		annotation := field.annotation
 
-					continue
+		if annotation == annotationPrimary {
+			if data.ID == "" {
+				continue
+			}
+
+			// Check the JSON API Type
+			if data.Type != field.name {
+				return &UnmarshalError{
+					Pointer:  pointer + "/type",
+					Field:    fieldType.Name,
+					Type:     fieldType.Type,
+					JSONKind: jsonKind(data.Type),
+					Err: fmt.Errorf(
+						"%w: Trying to Unmarshal an object of type %#v, but %#v does not match",
+						ErrTypeConflict,
+						data.Type,
+						field.name,
+					),
 				}
+			}
 
-				var at int64
+			member = pointer + "/id"
 
-				if v.Kind() == reflect.Float64 {
-					at = int64(v.Interface().(float64))
-				} else if v.Kind() == reflect.Int {
-					at = v.Int()
-				} else {
-					er = ErrInvalidTime
-					break
+			idValue, err := unmarshalID(data.ID, fieldValue)
+			if err != nil {
+				return &UnmarshalError{
+					Pointer:  member,
+					Field:    fieldType.Name,
+					Type:     fieldType.Type,
+					JSONKind: jsonKind(data.ID),
+					Err:      err,
 				}
+			}
 
-				v := time.Unix(at, 0)
-				t := &v
+			assign(fieldValue, idValue)
+		} else if annotation == annotationClientID {
+			if data.ClientID == "" {
+				continue
+			}
 
-				fieldValue.Set(reflect.ValueOf(t))
+			member = pointer + "/client-id"
 
+			fieldValue.Set(reflect.ValueOf(data.ClientID))
+		} else if annotation == annotationAttribute {
+			attributes := data.Attributes
+			if attributes == nil || len(data.Attributes) == 0 {
 				continue
 			}
 
-			// JSON value was a float (numeric)
-			if v.Kind() == reflect.Float64 {
-				floatValue := v.Interface().(float64)
-
-				// The field may or may not be a pointer to a numeric; the kind var
-				// will not contain a pointer type
-				var kind reflect.Kind
-				if fieldValue.Kind() == reflect.Ptr {
-					kind = fieldType.Type.Elem().Kind()
-				} else {
-					kind = fieldType.Type.Kind()
-				}
-
-				var numericValue reflect.Value
-
-				switch kind {
-				case reflect.Int:
-					n := int(floatValue)
-					numericValue = reflect.ValueOf(&n)
-				case reflect.Int8:
-					n := int8(floatValue)
-					numericValue = reflect.ValueOf(&n)
-				case reflect.Int16:
-					n := int16(floatValue)
-					numericValue = reflect.ValueOf(&n)
-				case reflect.Int32:
-					n := int32(floatValue)
-					numericValue = reflect.ValueOf(&n)
-				case reflect.Int64:
-					n := int64(floatValue)
-					numericValue = reflect.ValueOf(&n)
-				case reflect.Uint:
-					n := uint(floatValue)
-					numericValue = reflect.ValueOf(&n)
-				case reflect.Uint8:
-					n := uint8(floatValue)
-					numericValue = reflect.ValueOf(&n)
-				case reflect.Uint16:
-					n := uint16(floatValue)
-					numericValue = reflect.ValueOf(&n)
-				case reflect.Uint32:
-					n := uint32(floatValue)
-					numericValue = reflect.ValueOf(&n)
-				case reflect.Uint64:
-					n := uint64(floatValue)
-					numericValue = reflect.ValueOf(&n)
-				case reflect.Float32:
-					n := float32(floatValue)
-					numericValue = reflect.ValueOf(&n)
-				case reflect.Float64:
-					n := float64(floatValue)
-					numericValue = reflect.ValueOf(&n)
-				default:
-					// We had a JSON float (numeric), but our field was a non numeric
-					// type
-					er = ErrUnknownFieldNumberType
-					break
-				}
+			val := attributes[field.name]
 
-				assign(fieldValue, numericValue)
+			// continue if the attribute was not included in the request
+			if val == nil {
 				continue
 			}
 
-			// Field was a Pointer type
-			if fieldValue.Kind() == reflect.Ptr {
-				var concreteVal reflect.Value
-
-				switch cVal := val.(type) {
-				case string:
-					concreteVal = reflect.ValueOf(&cVal)
-				case bool:
-					concreteVal = reflect.ValueOf(&cVal)
-				case complex64:
-					concreteVal = reflect.ValueOf(&cVal)
-				case complex128:
-					concreteVal = reflect.ValueOf(&cVal)
-				case uintptr:
-					concreteVal = reflect.ValueOf(&cVal)
-				default:
-					er = ErrUnsupportedPtrType
-					break
-				}
+			member = pointer + "/attributes/" + escapePointer(field.name)
 
-				if fieldValue.Type() != concreteVal.Type() {
-					// TODO: use fmt.Errorf so that you can have a more informative
-					// message that reports the attempted type that was not supported.
-					er = ErrUnsupportedPtrType
-					break
+			if ok, err := unmarshalCustomAttribute(val, fieldValue); ok {
+				if err != nil {
+					return &UnmarshalError{
+						Pointer:  member,
+						Field:    fieldType.Name,
+						Type:     fieldType.Type,
+						JSONKind: jsonKind(val),
+						Err:      err,
+					}
 				}
 
-				fieldValue.Set(concreteVal)
 				continue
 			}
 
-			fieldValue.Set(reflect.ValueOf(val))
+			value, err := unmarshalAttribute(val, field, fieldValue)
+			if err != nil {
+				return &UnmarshalError{
+					Pointer:  member,
+					Field:    fieldType.Name,
+					Type:     fieldType.Type,
+					JSONKind: jsonKind(val),
+					Err:      err,
+				}
+			}
 
-		} else if annotation == "relation" {
-			isSlice := fieldValue.Type().Kind() == reflect.Slice
+			assign(fieldValue, value)
+		} else if annotation == annotationRelation {
+			isSlice := field.toMany
 
-			if data.Relationships == nil || data.Relationships[args[1]] == nil {
+			if data.Relationships == nil || data.Relationships[field.name] == nil {
 				continue
 			}
 
+			member = pointer + "/relationships/" + escapePointer(field.name)
+
 			if isSlice {
 				// to-many relationship
 				relationship := new(RelationshipManyNode)
 
 				buf := bytes.NewBuffer(nil)
 
-				json.NewEncoder(buf).Encode(data.Relationships[args[1]])
-				json.NewDecoder(buf).Decode(relationship)
+				json.NewEncoder(buf).Encode(data.Relationships[field.name])
+				if err := json.NewDecoder(buf).Decode(relationship); err != nil {
+					return &UnmarshalError{
+						Pointer:  member + "/data",
+						Field:    fieldType.Name,
+						Type:     fieldType.Type,
+						JSONKind: relationshipDataKind(data.Relationships[field.name]),
+						Err:      ErrInvalidType,
+					}
+				}
+
+				if field.identifiers() {
+					identifiers := []*ResourceIdentifier{}
+					for i, n := range relationship.Data {
+						if n == nil {
+							return &UnmarshalError{
+								Pointer:  fmt.Sprintf("%s/data/%d", member, i),
+								Field:    fieldType.Name,
+								Type:     fieldType.Type,
+								JSONKind: jsonKind(nil),
+								Err:      ErrInvalidType,
+							}
+						}
+
+						identifier := resourceIdentifier(n)
+						identifiers = append(identifiers, identifier)
+
+						if err := unmarshalRelationshipIncluded(model, field.name, identifier,
+							isIncluded(n, included)); err != nil {
+							return err
+						}
+					}
+
+					fieldValue.Set(reflect.ValueOf(identifiers))
+
+					if err := unmarshalRelationshipMeta(model, field.name, relationship.Meta); err != nil {
+						return err
+					}
+					continue
+				}
 
-				data := relationship.Data
 				models := reflect.New(fieldValue.Type()).Elem()
 
-				for _, n := range data {
-					m := reflect.New(fieldValue.Type().Elem().Elem())
+				for i, n := range relationship.Data {
+					linkagePointer := fmt.Sprintf("%s/data/%d", member, i)
+
+					if n == nil {
+						return &UnmarshalError{
+							Pointer:  linkagePointer,
+							Field:    fieldType.Name,
+							Type:     fieldType.Type,
+							JSONKind: jsonKind(nil),
+							Err:      ErrInvalidType,
+						}
+					}
 
-					if err := unmarshalNode(
-						fullNode(n, included),
-						m,
-						included,
-					); err != nil {
-						er = err
-						break
+					m, err := newRelated(fieldValue.Type().Elem(), n)
+					if err != nil {
+						return &UnmarshalError{
+							Pointer:  linkagePointer + "/type",
+							Field:    fieldType.Name,
+							Type:     fieldType.Type,
+							JSONKind: jsonKind(n.Type),
+							Err:      err,
+						}
+					}
+
+					node, nodePointer := fullNode(n, included, linkagePointer)
+					if err := unmarshalNode(node, m, included, nodePointer); err != nil {
+						return err
+					}
+
+					if err := unmarshalRelationshipIncluded(model, field.name,
+						resourceIdentifier(n), isIncluded(n, included)); err != nil {
+						return err
 					}
 
 					models = reflect.Append(models, m)
 				}
 
 				fieldValue.Set(models)
+
+				if err := unmarshalRelationshipMeta(model, field.name, relationship.Meta); err != nil {
+					return err
+				}
 			} else {
 				// to-one relationships
 				relationship := new(RelationshipOneNode)
@@ -508,9 +584,21 @@ func unmarshalNode(data *Node, model reflect.Value, included *map[string]*Node)
 				buf := bytes.NewBuffer(nil)
 
 				json.NewEncoder(buf).Encode(
-					data.Relationships[args[1]],
+					data.Relationships[field.name],
 				)
-				json.NewDecoder(buf).Decode(relationship)
+				if err := json.NewDecoder(buf).Decode(relationship); err != nil {
+					return &UnmarshalError{
+						Pointer:  member + "/data",
+						Field:    fieldType.Name,
+						Type:     fieldType.Type,
+						JSONKind: relationshipDataKind(data.Relationships[field.name]),
+						Err:      ErrInvalidType,
+					}
+				}
+
+				if err := unmarshalRelationshipMeta(model, field.name, relationship.Meta); err != nil {
+					return err
+				}
 
 				/*
 					http://jsonapi.org/format/#document-resource-object-relationships
@@ -522,48 +610,544 @@ func unmarshalNode(data *Node, model reflect.Value, included *map[string]*Node)
 					continue
 				}
 
-				m := reflect.New(fieldValue.Type().Elem())
-				if err := unmarshalNode(
-					fullNode(relationship.Data, included),
-					m,
-					included,
-				); err != nil {
-					er = err
-					break
+				identifier := resourceIdentifier(relationship.Data)
+				if err := unmarshalRelationshipIncluded(model, field.name, identifier,
+					isIncluded(relationship.Data, included)); err != nil {
+					return err
 				}
 
-				fieldValue.Set(m)
+				if field.identifiers() {
+					fieldValue.Set(reflect.ValueOf(identifier))
+					continue
+				}
+
+				m, err := newRelated(fieldValue.Type(), relationship.Data)
+				if err != nil {
+					return &UnmarshalError{
+						Pointer:  member + "/data/type",
+						Field:    fieldType.Name,
+						Type:     fieldType.Type,
+						JSONKind: jsonKind(relationship.Data.Type),
+						Err:      err,
+					}
+				}
 
+				node, nodePointer := fullNode(relationship.Data, included, member+"/data")
+				if err := unmarshalNode(node, m, included, nodePointer); err != nil {
+					return err
+				}
+
+				fieldValue.Set(m)
 			}
+		}
+	}
 
-		} else {
-			er = fmt.Errorf(unsuportedStructTagMsg, annotation)
+	if data.Meta != nil {
+		if metaModel, ok := model.Interface().(MetaUnmarshaler); ok {
+			return metaModel.UnmarshalJSONAPIMeta(data.Meta)
 		}
 	}
 
-	if er != nil {
-		return er
+	return nil
+}
+
+// unmarshalRelationshipMeta hands the meta object of the named relationship to
+// the model, if the model wants it.
+func unmarshalRelationshipMeta(model reflect.Value, relation string, meta *Meta) error {
+	if meta == nil {
+		return nil
+	}
+
+	if metaModel, ok := model.Interface().(RelationshipMetaUnmarshaler); ok {
+		return metaModel.UnmarshalJSONAPIRelationshipMeta(relation, meta)
+	}
+
+	return nil
+}
+
+// unmarshalRelationshipIncluded tells the model whether the related resource
+// identified by identifier was sent in full, if the model wants to know.
+func unmarshalRelationshipIncluded(model reflect.Value, relation string,
+	identifier *ResourceIdentifier, included bool) error {
+	if includedModel, ok := model.Interface().(RelationshipIncludedUnmarshaler); ok {
+		return includedModel.UnmarshalJSONAPIRelationshipIncluded(relation, identifier, included)
 	}
 
 	return nil
 }
 
-func fullNode(n *Node, included *map[string]*Node) *Node {
+// resourceIdentifier returns the resource identifier of the resource linkage n.
+func resourceIdentifier(n *Node) *ResourceIdentifier {
+	return &ResourceIdentifier{Type: n.Type, ID: n.ID, Meta: n.Meta}
+}
+
+// isIncluded reports whether the related resource of the resource linkage n
+// was sent in full, either in the "included" array or embedded in the linkage.
+func isIncluded(n *Node, included includedNodes) bool {
+	if n.Attributes != nil || n.Relationships != nil {
+		return true
+	}
+
+	return included[fmt.Sprintf("%s,%s", n.Type, n.ID)] != nil
+}
+
+// newRelated allocates the record for the relationship linkage n, held by a
+// relation field, or slice element, of type recordType: a pointer to a
+// struct, or an interface for polymorphic relationships, whose record type is
+// looked up among the models registered with Register.
+func newRelated(recordType reflect.Type, n *Node) (reflect.Value, error) {
+	if recordType.Kind() != reflect.Interface {
+		return reflect.New(recordType.Elem()), nil
+	}
+
+	modelType, ok := registeredType(n.Type)
+	if !ok {
+		return reflect.Value{}, ErrUnregisteredType
+	}
+
+	m := reflect.New(modelType)
+	if !m.Type().Implements(recordType) {
+		return reflect.Value{}, ErrInvalidType
+	}
+
+	return m, nil
+}
+
+// fullNode returns the resource object from the "included" array matching the
+// resource linkage n, along with its JSON pointer. If there is none, n and
+// pointer are returned as is.
+func fullNode(n *Node, included includedNodes, pointer string) (*Node, string) {
+	if n == nil {
+		return n, pointer
+	}
+
 	includedKey := fmt.Sprintf("%s,%s", n.Type, n.ID)
 
-	if included != nil && (*included)[includedKey] != nil {
-		return (*included)[includedKey]
+	if includedNode := included[includedKey]; includedNode != nil {
+		return includedNode.Node, includedNode.pointer
+	}
+
+	return n, pointer
+}
+
+func unmarshalID(id string, fieldValue reflect.Value) (reflect.Value, error) {
+	// Field types implementing IDUnmarshaler or encoding.TextUnmarshaler
+	// parse the id themselves
+	target := reflect.New(fieldValue.Type())
+	if fieldValue.Kind() == reflect.Ptr {
+		target = reflect.New(fieldValue.Type().Elem())
+	}
+
+	switch u := target.Interface().(type) {
+	case IDUnmarshaler:
+		if err := u.UnmarshalJSONAPIID(id); err != nil {
+			return reflect.Value{}, fmt.Errorf("%w: %v", ErrBadJSONAPIID, err)
+		}
+		return target, nil
+	case encoding.TextUnmarshaler:
+		if err := u.UnmarshalText([]byte(id)); err != nil {
+			return reflect.Value{}, fmt.Errorf("%w: %v", ErrBadJSONAPIID, err)
+		}
+		return target, nil
+	}
+
+	// ID will have to be transmitted as astring per the JSON API spec
+	v := reflect.ValueOf(id)
+
+	// Deal with PTRS
+	var kind reflect.Kind
+	if fieldValue.Kind() == reflect.Ptr {
+		kind = fieldValue.Type().Elem().Kind()
+	} else {
+		kind = fieldValue.Type().Kind()
+	}
+
+	// Handle String case
+	if kind == reflect.String {
+		return v, nil
+	}
+
+	// Value was not a string... only other supported type was a numeric,
+	// which would have been sent as a float value.
+	floatValue, err := strconv.ParseFloat(id, 64)
+	if err != nil {
+		// Could not convert the value in the "id" attr to a float
+		return reflect.Value{}, ErrBadJSONAPIID
+	}
+
+	// Convert the numeric float to one of the supported ID numeric types
+	// (int[8,16,32,64] or uint[8,16,32,64])
+	var idValue reflect.Value
+	switch kind {
+	case reflect.Int:
+		n := int(floatValue)
+		idValue = reflect.ValueOf(&n)
+	case reflect.Int8:
+		n := int8(floatValue)
+		idValue = reflect.ValueOf(&n)
+	case reflect.Int16:
+		n := int16(floatValue)
+		idValue = reflect.ValueOf(&n)
+	case reflect.Int32:
+		n := int32(floatValue)
+		idValue = reflect.ValueOf(&n)
+	case reflect.Int64:
+		n := int64(floatValue)
+		idValue = reflect.ValueOf(&n)
+	case reflect.Uint:
+		n := uint(floatValue)
+		idValue = reflect.ValueOf(&n)
+	case reflect.Uint8:
+		n := uint8(floatValue)
+		idValue = reflect.ValueOf(&n)
+	case reflect.Uint16:
+		n := uint16(floatValue)
+		idValue = reflect.ValueOf(&n)
+	case reflect.Uint32:
+		n := uint32(floatValue)
+		idValue = reflect.ValueOf(&n)
+	case reflect.Uint64:
+		n := uint64(floatValue)
+		idValue = reflect.ValueOf(&n)
+	default:
+		// We had a JSON float (numeric), but our field was not one of the
+		// allowed numeric types
+		return reflect.Value{}, ErrBadJSONAPIID
+	}
+
+	return idValue, nil
+}
+
+// unmarshalCustomAttribute unmarshals the attribute into fields implementing
+// AttributeUnmarshaler, json.Unmarshaler or encoding.TextUnmarshaler, in that
+// order, allocating pointer fields.  It reports false for other fields and
+// times, which are parsed according to the iso8601 option.
+func unmarshalCustomAttribute(attribute interface{}, fieldValue reflect.Value) (bool, error) {
+	target := fieldValue
+	if fieldValue.Kind() == reflect.Ptr {
+		target = reflect.New(fieldValue.Type().Elem())
+	} else {
+		target = fieldValue.Addr()
+	}
+
+	if isTimeType(target.Type()) {
+		return false, nil
+	}
+
+	var err error
+
+	switch u := target.Interface().(type) {
+	case AttributeUnmarshaler:
+		err = u.UnmarshalJSONAPIAttribute(attribute)
+	case json.Unmarshaler:
+		var buf []byte
+		if buf, err = json.Marshal(attribute); err == nil {
+			err = u.UnmarshalJSON(buf)
+		}
+	case encoding.TextUnmarshaler:
+		text, ok := attribute.(string)
+		if !ok {
+			return true, ErrInvalidType
+		}
+		err = u.UnmarshalText([]byte(text))
+	default:
+		return false, nil
+	}
+
+	if err != nil {
+		return true, err
+	}
+
+	if fieldValue.Kind() == reflect.Ptr {
+		fieldValue.Set(target)
 	}
 
-	return n
+	return true, nil
+}
+
+// isTimeType reports whether t is time.Time or a pointer to it.
+func isTimeType(t reflect.Type) bool {
+	return t == reflect.TypeOf(time.Time{}) || t == reflect.TypeOf(new(time.Time))
+}
+
+func unmarshalAttribute(attribute interface{}, field *fieldSchema,
+	fieldValue reflect.Value) (reflect.Value, error) {
+	value := reflect.ValueOf(attribute)
+	fieldType := field.structField.Type
+
+	// Handle field of type []string
+	if fieldValue.Type() == reflect.TypeOf([]string(nil)) {
+		return handleStringSlice(attribute)
+	}
+
+	// Handle field of type time.Time
+	if fieldValue.Type() == reflect.TypeOf(time.Time{}) ||
+		fieldValue.Type() == reflect.TypeOf(new(time.Time)) {
+		return handleTime(attribute, field.iso8601)
+	}
+
+	// Handle fields of struct, map, slice and array types, including
+	// pointers to them
+	if isComplexType(fieldType) {
+		return handleComplex(attribute, fieldType)
+	}
+
+	// JSON value was a float (numeric)
+	if value.Kind() == reflect.Float64 {
+		return handleNumeric(attribute, fieldType)
+	}
+
+	// Field was a Pointer type
+	if fieldValue.Kind() == reflect.Ptr {
+		return handlePointer(attribute, fieldType)
+	}
+
+	// As a final catch-all, ensure types line up to avoid a runtime panic.
+	if fieldValue.Kind() != value.Kind() ||
+		!value.Type().ConvertibleTo(fieldValue.Type()) {
+		return reflect.Value{}, ErrInvalidType
+	}
+
+	return value, nil
+}
+
+func handleStringSlice(attribute interface{}) (reflect.Value, error) {
+	v, ok := attribute.([]interface{})
+	if !ok {
+		return reflect.Value{}, ErrInvalidType
+	}
+
+	values := make([]string, len(v))
+	for i := range v {
+		s, ok := v[i].(string)
+		if !ok {
+			return reflect.Value{}, ErrInvalidType
+		}
+		values[i] = s
+	}
+
+	return reflect.ValueOf(values), nil
+}
+
+func handleTime(attribute interface{}, iso8601 bool) (reflect.Value, error) {
+	if iso8601 {
+		tm, ok := attribute.(string)
+		if !ok {
+			return reflect.Value{}, ErrInvalidISO8601
+		}
+
+		t, err := time.Parse(iso8601TimeFormat, tm)
+		if err != nil {
+			return reflect.Value{}, ErrInvalidISO8601
+		}
+
+		return reflect.ValueOf(t), nil
+	}
+
+	var at int64
+
+	switch v := attribute.(type) {
+	case float64:
+		at = int64(v)
+	case int:
+		at = int64(v)
+	default:
+		return reflect.Value{}, ErrInvalidTime
+	}
+
+	return reflect.ValueOf(time.Unix(at, 0)), nil
+}
+
+// isComplexType reports whether t, or the type it points to, is a struct,
+// map, slice or array type.
+func isComplexType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	}
+
+	return false
+}
+
+// handleComplex decodes the attribute into a new value of fieldType, or of the
+// type it points to, by way of encoding/json so that the json struct tags of
+// nested types are honoured.
+func handleComplex(attribute interface{}, fieldType reflect.Type) (reflect.Value, error) {
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	buf, err := json.Marshal(attribute)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	complexValue := reflect.New(fieldType)
+	if err := json.Unmarshal(buf, complexValue.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("%w: %v", ErrInvalidType, err)
+	}
+
+	return complexValue, nil
+}
+
+func handleNumeric(attribute interface{}, fieldType reflect.Type) (reflect.Value, error) {
+	floatValue := attribute.(float64)
+
+	// The field may or may not be a pointer to a numeric; the kind var
+	// will not contain a pointer type
+	var kind reflect.Kind
+	if fieldType.Kind() == reflect.Ptr {
+		kind = fieldType.Elem().Kind()
+	} else {
+		kind = fieldType.Kind()
+	}
+
+	var numericValue reflect.Value
+
+	switch kind {
+	case reflect.Int:
+		n := int(floatValue)
+		numericValue = reflect.ValueOf(&n)
+	case reflect.Int8:
+		n := int8(floatValue)
+		numericValue = reflect.ValueOf(&n)
+	case reflect.Int16:
+		n := int16(floatValue)
+		numericValue = reflect.ValueOf(&n)
+	case reflect.Int32:
+		n := int32(floatValue)
+		numericValue = reflect.ValueOf(&n)
+	case reflect.Int64:
+		n := int64(floatValue)
+		numericValue = reflect.ValueOf(&n)
+	case reflect.Uint:
+		n := uint(floatValue)
+		numericValue = reflect.ValueOf(&n)
+	case reflect.Uint8:
+		n := uint8(floatValue)
+		numericValue = reflect.ValueOf(&n)
+	case reflect.Uint16:
+		n := uint16(floatValue)
+		numericValue = reflect.ValueOf(&n)
+	case reflect.Uint32:
+		n := uint32(floatValue)
+		numericValue = reflect.ValueOf(&n)
+	case reflect.Uint64:
+		n := uint64(floatValue)
+		numericValue = reflect.ValueOf(&n)
+	case reflect.Float32:
+		n := float32(floatValue)
+		numericValue = reflect.ValueOf(&n)
+	case reflect.Float64:
+		n := float64(floatValue)
+		numericValue = reflect.ValueOf(&n)
+	default:
+		// We had a JSON float (numeric), but our field was a non numeric
+		// type
+		return reflect.Value{}, ErrUnknownFieldNumberType
+	}
+
+	return numericValue, nil
+}
+
+func handlePointer(attribute interface{}, fieldType reflect.Type) (reflect.Value, error) {
+	var concreteVal reflect.Value
+
+	switch cVal := attribute.(type) {
+	case string:
+		concreteVal = reflect.ValueOf(&cVal)
+	case bool:
+		concreteVal = reflect.ValueOf(&cVal)
+	case complex64:
+		concreteVal = reflect.ValueOf(&cVal)
+	case complex128:
+		concreteVal = reflect.ValueOf(&cVal)
+	case uintptr:
+		concreteVal = reflect.ValueOf(&cVal)
+	default:
+		return reflect.Value{}, ErrUnsupportedPtrType
+	}
+
+	if fieldType.Elem().Kind() != concreteVal.Elem().Kind() {
+		return reflect.Value{}, ErrUnsupportedPtrType
+	}
+
+	return concreteVal, nil
 }
 
 // assign will take the value specified and assign it to the field; if
 // field is expecting a ptr assign will assign a ptr.
 func assign(field, value reflect.Value) {
+	value = reflect.Indirect(value)
+
 	if field.Kind() == reflect.Ptr {
-		field.Set(value)
-	} else {
-		field.Set(reflect.Indirect(value))
+		// initialize the pointer so its value can be set by assignValue
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+
+	assignValue(field, value)
+}
+
+// assignValue assigns the specified value to the field, expecting neither of
+// them to be pointer types; named types are set through their underlying kind.
+func assignValue(field, value reflect.Value) {
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		field.SetUint(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		field.SetFloat(value.Float())
+	case reflect.String:
+		field.SetString(value.String())
+	case reflect.Bool:
+		field.SetBool(value.Bool())
+	default:
+		field.Set(value.Convert(field.Type()))
+	}
+}
+
+// jsonKind describes the kind of a decoded JSON value the way the JSON
+// specification names it.
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64, json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+
+	return fmt.Sprintf("%T", v)
+}
+
+// relationshipDataKind describes the kind of the "data" member of a decoded
+// relationship object.
+func relationshipDataKind(relationship interface{}) string {
+	if r, ok := relationship.(map[string]interface{}); ok {
+		return jsonKind(r["data"])
 	}
+
+	return jsonKind(relationship)
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// escapePointer escapes a member name for use as a JSON pointer reference
+// token, see https://tools.ietf.org/html/rfc6901#section-3
+func escapePointer(name string) string {
+	return pointerEscaper.Replace(name)
 }
@@ -2,8 +2,13 @@ package jsonapi
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -97,6 +102,647 @@ func TestUnmarshalPayloadWithPointerAttr_AbsentVal(t *testing.T) {
 	}
 }
 
+type Address struct {
+	Street   string `json:"street"`
+	City     string `json:"city"`
+	Postcode string `json:"postcode,omitempty"`
+}
+
+type Tag struct {
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+}
+
+type Venue struct {
+	ID       string            `jsonapi:"primary,venues"`
+	Address  Address           `jsonapi:"attr,address"`
+	Billing  *Address          `jsonapi:"attr,billing,omitempty"`
+	Tags     []Tag             `jsonapi:"attr,tags,omitempty"`
+	Ratings  []int             `jsonapi:"attr,ratings,omitempty"`
+	Settings map[string]string `jsonapi:"attr,settings,omitempty"`
+}
+
+func TestUnmarshalComplexAttributes(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": {
+			"type": "venues",
+			"id": "1",
+			"attributes": {
+				"address": {"street": "1 Main St", "city": "Springfield"},
+				"billing": {"street": "PO Box 9", "city": "Shelbyville"},
+				"tags": [{"name": "cosy", "score": 4}, {"name": "loud", "score": 1}],
+				"ratings": [5, 3],
+				"settings": {"theme": "dark"}
+			}
+		}
+	}`)
+	out := new(Venue)
+
+	if err := UnmarshalPayload(in, out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Venue{
+		ID:       "1",
+		Address:  Address{Street: "1 Main St", City: "Springfield"},
+		Billing:  &Address{Street: "PO Box 9", City: "Shelbyville"},
+		Tags:     []Tag{{Name: "cosy", Score: 4}, {Name: "loud", Score: 1}},
+		Ratings:  []int{5, 3},
+		Settings: map[string]string{"theme": "dark"},
+	}
+	if !reflect.DeepEqual(expected, out) {
+		t.Fatalf("Was expecting %#v, got %#v", expected, out)
+	}
+}
+
+func TestUnmarshalComplexAttributeInvalidType(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": {
+			"type": "venues",
+			"id": "1",
+			"attributes": {"tags": [{"name": "cosy", "score": "high"}]}
+		}
+	}`)
+
+	err := UnmarshalPayload(in, new(Venue))
+
+	unmarshalError, ok := err.(*UnmarshalError)
+	if !ok {
+		t.Fatalf("Was expecting an *UnmarshalError, got %v", err)
+	}
+	if !errors.Is(err, ErrInvalidType) {
+		t.Fatalf("Was expecting ErrInvalidType, got %v", err)
+	}
+	if e, a := "/data/attributes/tags", unmarshalError.Pointer; e != a {
+		t.Fatalf("Was expecting the pointer %s, got %s", e, a)
+	}
+}
+
+// Cents is marshaled as a decimal string, e.g. "12.34"
+type Cents int64
+
+func (c Cents) MarshalJSONAPIAttribute() (interface{}, error) {
+	return fmt.Sprintf("%d.%02d", c/100, c%100), nil
+}
+
+func (c *Cents) UnmarshalJSONAPIAttribute(value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		return ErrInvalidType
+	}
+
+	var units, cents int64
+	if _, err := fmt.Sscanf(s, "%d.%02d", &units, &cents); err != nil {
+		return err
+	}
+	*c = Cents(units*100 + cents)
+
+	return nil
+}
+
+type Level int
+
+var levels = []string{"low", "medium", "high"}
+
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(levels[l]), nil
+}
+
+func (l *Level) UnmarshalText(text []byte) error {
+	for i, name := range levels {
+		if name == string(text) {
+			*l = Level(i)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown level %q", text)
+}
+
+// Point is marshaled as a [x, y] pair
+type Point struct {
+	X, Y int
+}
+
+func (p *Point) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]int{p.X, p.Y})
+}
+
+func (p *Point) UnmarshalJSON(data []byte) error {
+	var pair []int
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("was expecting a pair, got %v", pair)
+	}
+	p.X, p.Y = pair[0], pair[1]
+
+	return nil
+}
+
+type Order struct {
+	ID       string `jsonapi:"primary,orders"`
+	Total    Cents  `jsonapi:"attr,total"`
+	Discount *Cents `jsonapi:"attr,discount,omitempty"`
+	Priority Level  `jsonapi:"attr,priority"`
+	Location Point  `jsonapi:"attr,location"`
+}
+
+func TestUnmarshalCustomAttributes(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": {
+			"type": "orders",
+			"id": "1",
+			"attributes": {
+				"total": "12.34",
+				"discount": "0.50",
+				"priority": "high",
+				"location": [3, 4]
+			}
+		}
+	}`)
+	out := new(Order)
+
+	if err := UnmarshalPayload(in, out); err != nil {
+		t.Fatal(err)
+	}
+
+	discount := Cents(50)
+	expected := &Order{
+		ID:       "1",
+		Total:    1234,
+		Discount: &discount,
+		Priority: 2,
+		Location: Point{3, 4},
+	}
+	if !reflect.DeepEqual(expected, out) {
+		t.Fatalf("Was expecting %#v, got %#v", expected, out)
+	}
+}
+
+func TestUnmarshalCustomAttributeErrors(t *testing.T) {
+	for attribute, value := range map[string]string{
+		"total":    `12.34`,
+		"priority": `"urgent"`,
+		"location": `[1]`,
+	} {
+		in := strings.NewReader(`{
+			"data": {
+				"type": "orders",
+				"id": "1",
+				"attributes": {"` + attribute + `": ` + value + `}
+			}
+		}`)
+
+		err := UnmarshalPayload(in, new(Order))
+
+		unmarshalError, ok := err.(*UnmarshalError)
+		if !ok {
+			t.Fatalf("Was expecting an *UnmarshalError for %s, got %v", attribute, err)
+		}
+		if e, a := "/data/attributes/"+attribute, unmarshalError.Pointer; e != a {
+			t.Fatalf("Was expecting the pointer %s, got %s", e, a)
+		}
+	}
+}
+
+// UUID is formatted as 32 hex digits
+type UUID [16]byte
+
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(u[:])), nil
+}
+
+func (u *UUID) UnmarshalText(text []byte) error {
+	if hex.DecodedLen(len(text)) != len(u) {
+		return fmt.Errorf("invalid uuid %q", text)
+	}
+	_, err := hex.Decode(u[:], text)
+	return err
+}
+
+// LineKey identifies an order line, formatted as "<order>-<line>"
+type LineKey struct {
+	Order string
+	Line  int
+}
+
+func (k LineKey) MarshalJSONAPIID() (string, error) {
+	return fmt.Sprintf("%s-%d", k.Order, k.Line), nil
+}
+
+func (k *LineKey) UnmarshalJSONAPIID(id string) error {
+	i := strings.LastIndex(id, "-")
+	if i < 0 {
+		return fmt.Errorf("invalid line key %q", id)
+	}
+
+	line, err := strconv.Atoi(id[i+1:])
+	if err != nil {
+		return err
+	}
+	k.Order, k.Line = id[:i], line
+
+	return nil
+}
+
+type Sku string
+
+type Product struct {
+	ID  UUID `jsonapi:"primary,products"`
+	Sku Sku  `jsonapi:"attr,sku"`
+}
+
+type OrderLine struct {
+	Key     LineKey  `jsonapi:"primary,order-lines"`
+	Product *Product `jsonapi:"relation,product"`
+}
+
+type SkuProduct struct {
+	ID Sku `jsonapi:"primary,products"`
+}
+
+func TestUnmarshalCustomIDs(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": {
+			"type": "order-lines",
+			"id": "abc-2",
+			"relationships": {
+				"product": {
+					"data": {"type": "products", "id": "00112233445566778899aabbccddeeff"}
+				}
+			}
+		}
+	}`)
+	out := new(OrderLine)
+
+	if err := UnmarshalPayload(in, out); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := (LineKey{"abc", 2}), out.Key; e != a {
+		t.Fatalf("Was expecting the key %v, got %v", e, a)
+	}
+	uuid := UUID{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77,
+		0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	if out.Product == nil || out.Product.ID != uuid {
+		t.Fatalf("Was expecting the product id %v, got %v", uuid, out.Product)
+	}
+
+	sku := new(SkuProduct)
+	if err := UnmarshalPayload(strings.NewReader(`{
+		"data": {"type": "products", "id": "SKU-1"}
+	}`), sku); err != nil {
+		t.Fatal(err)
+	}
+	if sku.ID != "SKU-1" {
+		t.Fatalf("Was expecting the id SKU-1, got %s", sku.ID)
+	}
+}
+
+func TestUnmarshalInvalidCustomID(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": {"type": "order-lines", "id": "abc"}
+	}`)
+
+	err := UnmarshalPayload(in, new(OrderLine))
+	if !errors.Is(err, ErrBadJSONAPIID) {
+		t.Fatalf("Was expecting ErrBadJSONAPIID, got %v", err)
+	}
+	if e, a := "/data/id", err.(*UnmarshalError).Pointer; e != a {
+		t.Fatalf("Was expecting the pointer %s, got %s", e, a)
+	}
+}
+
+// Attachment is implemented by the records of polymorphic relationships
+type Attachment interface {
+	attachment()
+}
+
+type Photo struct {
+	ID  int    `jsonapi:"primary,photos"`
+	URL string `jsonapi:"attr,url"`
+}
+
+func (*Photo) attachment() {}
+
+type Video struct {
+	ID        int    `jsonapi:"primary,videos"`
+	URL       string `jsonapi:"attr,url"`
+	Thumbnail *Photo `jsonapi:"relation,thumbnail"`
+}
+
+func (*Video) attachment() {}
+
+type Message struct {
+	ID          int          `jsonapi:"primary,messages"`
+	Body        string       `jsonapi:"attr,body"`
+	Pinned      Attachment   `jsonapi:"relation,pinned"`
+	Attachments []Attachment `jsonapi:"relation,attachments"`
+}
+
+func init() {
+	MustRegister(new(Photo), new(Video))
+}
+
+func TestUnmarshalPolymorphicRelationships(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": {
+			"type": "messages",
+			"id": "1",
+			"relationships": {
+				"pinned": {"data": {"type": "videos", "id": "2"}},
+				"attachments": {
+					"data": [
+						{"type": "photos", "id": "1"},
+						{"type": "videos", "id": "2"}
+					]
+				}
+			}
+		},
+		"included": [
+			{"type": "photos", "id": "1", "attributes": {"url": "a.png"}},
+			{
+				"type": "videos",
+				"id": "2",
+				"attributes": {"url": "b.mp4"},
+				"relationships": {"thumbnail": {"data": {"type": "photos", "id": "1"}}}
+			}
+		]
+	}`)
+	out := new(Message)
+
+	if err := UnmarshalPayload(in, out); err != nil {
+		t.Fatal(err)
+	}
+
+	photo := &Photo{ID: 1, URL: "a.png"}
+	video := &Video{ID: 2, URL: "b.mp4", Thumbnail: photo}
+	expected := &Message{
+		ID:          1,
+		Pinned:      video,
+		Attachments: []Attachment{photo, video},
+	}
+	if !reflect.DeepEqual(expected, out) {
+		t.Fatalf("Was expecting %#v, got %#v", expected, out)
+	}
+}
+
+func TestUnmarshalPolymorphicRelationshipUnregisteredType(t *testing.T) {
+	for pointer, relationships := range map[string]string{
+		"/data/relationships/pinned/data/type":        `{"pinned": {"data": {"type": "audio", "id": "1"}}}`,
+		"/data/relationships/attachments/data/1/type": `{"attachments": {"data": [{"type": "photos", "id": "1"}, {"type": "audio", "id": "1"}]}}`,
+	} {
+		in := strings.NewReader(`{
+			"data": {"type": "messages", "id": "1", "relationships": ` + relationships + `}
+		}`)
+
+		err := UnmarshalPayload(in, new(Message))
+		if !errors.Is(err, ErrUnregisteredType) {
+			t.Fatalf("Was expecting ErrUnregisteredType, got %v", err)
+		}
+		if a := err.(*UnmarshalError).Pointer; pointer != a {
+			t.Fatalf("Was expecting the pointer %s, got %s", pointer, a)
+		}
+	}
+
+	// Registered types that do not implement the interface are rejected
+	in := strings.NewReader(`{
+		"data": {
+			"type": "messages",
+			"id": "1",
+			"relationships": {"pinned": {"data": {"type": "blogs", "id": "1"}}}
+		}
+	}`)
+	MustRegister(new(Blog))
+	if err := UnmarshalPayload(in, new(Message)); !errors.Is(err, ErrInvalidType) {
+		t.Fatalf("Was expecting ErrInvalidType, got %v", err)
+	}
+}
+
+func TestUnmarshalMixedManyPayload(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": [
+			{"type": "photos", "id": "1", "attributes": {"url": "a.png"}},
+			{
+				"type": "videos",
+				"id": "2",
+				"attributes": {"url": "b.mp4"},
+				"relationships": {"thumbnail": {"data": {"type": "photos", "id": "1"}}}
+			}
+		],
+		"meta": {"total": 2}
+	}`)
+
+	models, meta, err := UnmarshalMixedManyPayloadWithMeta(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []interface{}{
+		&Photo{ID: 1, URL: "a.png"},
+		&Video{ID: 2, URL: "b.mp4", Thumbnail: &Photo{ID: 1}},
+	}
+	if !reflect.DeepEqual(expected, models) {
+		t.Fatalf("Was expecting %#v, got %#v", expected, models)
+	}
+	if meta == nil || (*meta)["total"] != float64(2) {
+		t.Fatalf("Was expecting the top-level meta, got %v", meta)
+	}
+}
+
+func TestUnmarshalMixedManyPayloadUnregisteredType(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": [
+			{"type": "photos", "id": "1"},
+			{"type": "audio", "id": "2"}
+		]
+	}`)
+
+	_, err := UnmarshalMixedManyPayload(in)
+	if !errors.Is(err, ErrUnregisteredType) {
+		t.Fatalf("Was expecting ErrUnregisteredType, got %v", err)
+	}
+	if e, a := "/data/1/type", err.(*UnmarshalError).Pointer; e != a {
+		t.Fatalf("Was expecting the pointer %s, got %s", e, a)
+	}
+}
+
+type Playlist struct {
+	ID     int                   `jsonapi:"primary,playlists"`
+	Name   string                `jsonapi:"attr,name"`
+	Owner  *ResourceIdentifier   `jsonapi:"relation,owner"`
+	Tracks []*ResourceIdentifier `jsonapi:"relation,tracks"`
+}
+
+type TrackedPost struct {
+	ID            int        `jsonapi:"primary,posts"`
+	Comments      []*Comment `jsonapi:"relation,comments"`
+	LatestComment *Comment   `jsonapi:"relation,latest_comment"`
+	Included      map[string]bool
+}
+
+func (p *TrackedPost) UnmarshalJSONAPIRelationshipIncluded(relation string,
+	identifier *ResourceIdentifier, included bool) error {
+	if p.Included == nil {
+		p.Included = make(map[string]bool)
+	}
+	p.Included[relation+"/"+identifier.ID] = included
+
+	return nil
+}
+
+func TestUnmarshalResourceIdentifiers(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": {
+			"type": "playlists",
+			"id": "1",
+			"attributes": {"name": "Focus"},
+			"relationships": {
+				"owner": {"data": {"type": "users", "id": "7"}},
+				"tracks": {
+					"data": [
+						{"type": "tracks", "id": "1", "meta": {"position": 1}},
+						{"type": "tracks", "id": "2"}
+					]
+				}
+			}
+		},
+		"included": [{"type": "tracks", "id": "1", "attributes": {"title": "Intro"}}]
+	}`)
+	out := new(Playlist)
+
+	if err := UnmarshalPayload(in, out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Playlist{
+		ID:    1,
+		Name:  "Focus",
+		Owner: &ResourceIdentifier{Type: "users", ID: "7"},
+		Tracks: []*ResourceIdentifier{
+			{Type: "tracks", ID: "1", Meta: &Meta{"position": float64(1)}},
+			{Type: "tracks", ID: "2"},
+		},
+	}
+	if !reflect.DeepEqual(expected, out) {
+		t.Fatalf("Was expecting %#v, got %#v", expected, out)
+	}
+}
+
+func TestUnmarshalRelationshipIncluded(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": {
+			"type": "posts",
+			"id": "1",
+			"relationships": {
+				"comments": {
+					"data": [
+						{"type": "comments", "id": "1"},
+						{"type": "comments", "id": "2"},
+						{"type": "comments", "id": "3", "attributes": {"body": "embedded"}}
+					]
+				},
+				"latest_comment": {"data": {"type": "comments", "id": "1"}}
+			}
+		},
+		"included": [{"type": "comments", "id": "1", "attributes": {"body": "included"}}]
+	}`)
+	out := new(TrackedPost)
+
+	if err := UnmarshalPayload(in, out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]bool{
+		"comments/1":       true,
+		"comments/2":       false,
+		"comments/3":       true,
+		"latest_comment/1": true,
+	}
+	if !reflect.DeepEqual(expected, out.Included) {
+		t.Fatalf("Was expecting %v, got %v", expected, out.Included)
+	}
+}
+
+type MetaPost struct {
+	ID            int        `jsonapi:"primary,posts"`
+	Title         string     `jsonapi:"attr,title"`
+	Comments      []*Comment `jsonapi:"relation,comments"`
+	Meta          *Meta
+	CommentsMeta  *Meta
+	LatestComment *Comment `jsonapi:"relation,latest_comment"`
+}
+
+func (p *MetaPost) UnmarshalJSONAPIMeta(meta *Meta) error {
+	p.Meta = meta
+	return nil
+}
+
+func (p *MetaPost) UnmarshalJSONAPIRelationshipMeta(relation string, meta *Meta) error {
+	if relation == "comments" {
+		p.CommentsMeta = meta
+	}
+	return nil
+}
+
+func TestUnmarshalPayloadWithMeta(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": {
+			"type": "posts",
+			"id": "1",
+			"attributes": {"title": "Hello"},
+			"relationships": {
+				"comments": {
+					"data": [{"type": "comments", "id": "2"}],
+					"meta": {"count": 1}
+				}
+			},
+			"meta": {"revision": 3}
+		},
+		"meta": {"request_id": "abc"}
+	}`)
+	out := new(MetaPost)
+
+	meta, err := UnmarshalPayloadWithMeta(in, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if meta == nil || (*meta)["request_id"] != "abc" {
+		t.Fatalf("Was expecting the top-level meta to be returned, got %v", meta)
+	}
+	if out.Meta == nil || (*out.Meta)["revision"] != float64(3) {
+		t.Fatalf("Was expecting the resource meta to be set, got %v", out.Meta)
+	}
+	if out.CommentsMeta == nil || (*out.CommentsMeta)["count"] != float64(1) {
+		t.Fatalf("Was expecting the relationship meta to be set, got %v", out.CommentsMeta)
+	}
+}
+
+func TestUnmarshalManyPayloadWithMeta(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": [
+			{"type": "posts", "id": "1", "attributes": {"title": "A"}},
+			{"type": "posts", "id": "2", "attributes": {"title": "B"}}
+		],
+		"meta": {"total": 2}
+	}`)
+
+	models, meta, err := UnmarshalManyPayloadWithMeta(in, reflect.TypeOf(new(MetaPost)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(models) != 2 {
+		t.Fatalf("Was expecting 2 models, got %d", len(models))
+	}
+	if meta == nil || (*meta)["total"] != float64(2) {
+		t.Fatalf("Was expecting the top-level meta to be returned, got %v", meta)
+	}
+}
+
 func TestStringPointerField(t *testing.T) {
 	// Build Book payload
 	description := "Hello World!"
@@ -169,7 +815,7 @@ func TestUnmarshal_nonNumericID(t *testing.T) {
 	in := bytes.NewReader(payload)
 	out := new(Post)
 
-	if err := UnmarshalPayload(in, out); err != ErrBadJSONAPIID {
+	if err := UnmarshalPayload(in, out); !errors.Is(err, ErrBadJSONAPIID) {
 		t.Fatalf(
 			"Was expecting a `%s` error, got `%s`",
 			ErrBadJSONAPIID,
@@ -260,11 +906,131 @@ func TestUnmarshalInvalidISO8601(t *testing.T) {
 
 	out := new(Timestamp)
 
-	if err := UnmarshalPayload(in, out); err != ErrInvalidISO8601 {
+	if err := UnmarshalPayload(in, out); !errors.Is(err, ErrInvalidISO8601) {
 		t.Fatalf("Expected ErrInvalidISO8601, got %v", err)
 	}
 }
 
+func TestUnmarshalErrorPointsAtAttribute(t *testing.T) {
+	payload := &OnePayload{
+		Data: &Node{
+			Type: "timestamps",
+			Attributes: map[string]interface{}{
+				"timestamp": true,
+			},
+		},
+	}
+
+	in := bytes.NewBuffer(nil)
+	json.NewEncoder(in).Encode(payload)
+
+	err := UnmarshalPayload(in, new(Timestamp))
+
+	var unmarshalErr *UnmarshalError
+	if !errors.As(err, &unmarshalErr) {
+		t.Fatalf("Was expecting an *UnmarshalError, got %v", err)
+	}
+	if e, a := "/data/attributes/timestamp", unmarshalErr.Pointer; e != a {
+		t.Fatalf("Was expecting the pointer to be %q, got %q", e, a)
+	}
+	if e, a := "Time", unmarshalErr.Field; e != a {
+		t.Fatalf("Was expecting the field to be %q, got %q", e, a)
+	}
+	if e, a := reflect.TypeOf(time.Time{}), unmarshalErr.Type; e != a {
+		t.Fatalf("Was expecting the type to be %v, got %v", e, a)
+	}
+	if e, a := "boolean", unmarshalErr.JSONKind; e != a {
+		t.Fatalf("Was expecting the JSON kind to be %q, got %q", e, a)
+	}
+	if !errors.Is(err, ErrInvalidISO8601) {
+		t.Fatalf("Was expecting the error to match ErrInvalidISO8601")
+	}
+
+	errorObject := unmarshalErr.ErrorObject()
+	if errorObject.Status != "422" || errorObject.Source.Pointer != unmarshalErr.Pointer {
+		t.Fatalf("Unexpected error object %#v", errorObject)
+	}
+}
+
+func TestUnmarshalErrorInvalidType(t *testing.T) {
+	in := strings.NewReader(`{"data": {"type": "blogs", "id": "1", "attributes": {"view_count": "many"}}}`)
+
+	err := UnmarshalPayload(in, new(Blog))
+
+	if !errors.Is(err, ErrInvalidType) {
+		t.Fatalf("Was expecting ErrInvalidType, got %v", err)
+	}
+
+	var unmarshalErr *UnmarshalError
+	if !errors.As(err, &unmarshalErr) {
+		t.Fatalf("Was expecting an *UnmarshalError, got %v", err)
+	}
+	if e, a := "/data/attributes/view_count", unmarshalErr.Pointer; e != a {
+		t.Fatalf("Was expecting the pointer to be %q, got %q", e, a)
+	}
+	if e, a := "string", unmarshalErr.JSONKind; e != a {
+		t.Fatalf("Was expecting the JSON kind to be %q, got %q", e, a)
+	}
+}
+
+func TestUnmarshalErrorPointsIntoIncluded(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": {
+			"type": "posts",
+			"id": "1",
+			"relationships": {
+				"comments": {
+					"data": [
+						{"type": "comments", "id": "1"},
+						{"type": "comments", "id": "2"}
+					]
+				}
+			}
+		},
+		"included": [
+			{"type": "comments", "id": "1", "attributes": {"body": "foo"}},
+			{"type": "comments", "id": "2", "attributes": {"body": 5}}
+		]
+	}`)
+
+	err := UnmarshalPayload(in, new(Post))
+
+	var unmarshalErr *UnmarshalError
+	if !errors.As(err, &unmarshalErr) {
+		t.Fatalf("Was expecting an *UnmarshalError, got %v", err)
+	}
+	if e, a := "/included/1/attributes/body", unmarshalErr.Pointer; e != a {
+		t.Fatalf("Was expecting the pointer to be %q, got %q", e, a)
+	}
+}
+
+func TestUnmarshalErrorPointsIntoEmbeddedRelationship(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": {
+			"type": "posts",
+			"id": "1",
+			"relationships": {
+				"latest_comment": {
+					"data": {"type": "comments", "id": "abc"}
+				}
+			}
+		}
+	}`)
+
+	err := UnmarshalPayload(in, new(Post))
+
+	var unmarshalErr *UnmarshalError
+	if !errors.As(err, &unmarshalErr) {
+		t.Fatalf("Was expecting an *UnmarshalError, got %v", err)
+	}
+	if e, a := "/data/relationships/latest_comment/data/id", unmarshalErr.Pointer; e != a {
+		t.Fatalf("Was expecting the pointer to be %q, got %q", e, a)
+	}
+	if !errors.Is(err, ErrBadJSONAPIID) {
+		t.Fatalf("Was expecting the error to match ErrBadJSONAPIID")
+	}
+}
+
 func TestUnmarshalRelationshipsWithoutIncluded(t *testing.T) {
 	data, _ := payload(samplePayloadWithoutIncluded())
 	in := bytes.NewReader(data)
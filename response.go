@@ -1,13 +1,13 @@
 package jsonapi
 
 import (
+	"encoding"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"reflect"
 	"strconv"
-	"strings"
 	"time"
 )
 
@@ -25,6 +25,81 @@ var (
 	ErrExpectedSlice = errors.New("models should be a slice of struct pointers")
 )
 
+// MarshalOption configures how models are marshaled by the Marshal functions,
+// e.g. WithFields.
+type MarshalOption func(*marshalOptions)
+
+// marshalOptions holds the configuration built from a set of MarshalOptions.
+type marshalOptions struct {
+	// fields maps a resource type to the set of its attributes and
+	// relationships that should be marshaled (sparse fieldsets)
+	fields map[string]map[string]bool
+	// include holds the relationship paths, relative to the records being
+	// marshaled, whose records should be sideloaded; nil means all of them
+	include includeTree
+	// linkageOnly is set when only the resource identifiers of the records
+	// being marshaled are needed
+	linkageOnly bool
+	// validate is set by the ValidateOutput option
+	validate bool
+}
+
+func newMarshalOptions(opts []MarshalOption) *marshalOptions {
+	options := new(marshalOptions)
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return options
+}
+
+// fieldset returns the set of attributes and relationships of the given type
+// that should be marshaled, or nil if all of them should be.
+func (o *marshalOptions) fieldset(typ string) map[string]bool {
+	if o == nil {
+		return nil
+	}
+
+	if o.linkageOnly {
+		return map[string]bool{}
+	}
+
+	if o.fields == nil {
+		return nil
+	}
+
+	return o.fields[typ]
+}
+
+// includes reports whether the records of the named relationship of the
+// records marshaled with o were requested with WithInclude.
+func (o *marshalOptions) includes(relation string) bool {
+	if o == nil || o.include == nil {
+		return false
+	}
+
+	_, ok := o.include[relation]
+	return ok
+}
+
+// descend returns the options with which to marshal the records of the named
+// relationship of the records marshaled with o.
+func (o *marshalOptions) descend(relation string) *marshalOptions {
+	if o == nil || o.include == nil {
+		return o
+	}
+
+	d := *o
+	if subtree, ok := o.include[relation]; ok {
+		d.include = subtree
+	} else {
+		d.include = includeTree{}
+		d.linkageOnly = true
+	}
+
+	return &d
+}
+
 // MarshalOnePayload writes a jsonapi response with one, with related records
 // sideloaded, into "included" array. This method encodes a response for a
 // single record only. Hence, data will be a single record rather than an array
@@ -33,8 +108,8 @@ var (
 // See UnmarshalPayload for usage example.
 //
 // model interface{} should be a pointer to a struct.
-func MarshalOnePayload(w io.Writer, model interface{}) error {
-	payload, err := MarshalOne(model)
+func MarshalOnePayload(w io.Writer, model interface{}, opts ...MarshalOption) error {
+	payload, err := MarshalOne(model, opts...)
 	if err != nil {
 		return err
 	}
@@ -51,15 +126,22 @@ func MarshalOnePayload(w io.Writer, model interface{}) error {
 // serialzie the realtions into the "included" array see MarshalOnePayload.
 //
 // model interface{} should be a pointer to a struct.
-func MarshalOnePayloadWithoutIncluded(w io.Writer, model interface{}) error {
+func MarshalOnePayloadWithoutIncluded(w io.Writer, model interface{},
+	opts ...MarshalOption) error {
 	included := make(map[string]*Node)
+	options := newMarshalOptions(opts)
 
-	rootNode, err := visitModelNode(model, &included, true)
+	rootNode, err := visitModelNode(model, &included, true, options)
 	if err != nil {
 		return err
 	}
 
-	if err := json.NewEncoder(w).Encode(&OnePayload{Data: rootNode}); err != nil {
+	payload := &OnePayload{Data: rootNode}
+	if err := validatePayload(payload, options); err != nil {
+		return err
+	}
+
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
 		return err
 	}
 
@@ -69,10 +151,11 @@ func MarshalOnePayloadWithoutIncluded(w io.Writer, model interface{}) error {
 // MarshalOne does the same as MarshalOnePayload except it just returns the
 // payload and doesn't write out results. Useful is you use your JSON rendering
 // library.
-func MarshalOne(model interface{}) (*OnePayload, error) {
+func MarshalOne(model interface{}, opts ...MarshalOption) (*OnePayload, error) {
 	included := make(map[string]*Node)
+	options := newMarshalOptions(opts)
 
-	rootNode, err := visitModelNode(model, &included, true)
+	rootNode, err := visitModelNode(model, &included, true, options)
 	if err != nil {
 		return nil, err
 	}
@@ -80,6 +163,10 @@ func MarshalOne(model interface{}) (*OnePayload, error) {
 
 	payload.Included = nodeMapValues(&included)
 
+	if err := validatePayload(payload, options); err != nil {
+		return nil, err
+	}
+
 	return payload, nil
 }
 
@@ -108,12 +195,12 @@ func MarshalOne(model interface{}) (*OnePayload, error) {
 // Visit https://github.com/google/jsonapi#list for more info.
 //
 // models interface{} should be a slice of struct pointers.
-func MarshalManyPayload(w io.Writer, models interface{}) error {
+func MarshalManyPayload(w io.Writer, models interface{}, opts ...MarshalOption) error {
 	m, err := convertToSliceInterface(&models)
 	if err != nil {
 		return err
 	}
-	payload, err := MarshalMany(m)
+	payload, err := MarshalMany(m, opts...)
 	if err != nil {
 		return err
 	}
@@ -128,14 +215,15 @@ func MarshalManyPayload(w io.Writer, models interface{}) error {
 // MarshalMany does the same as MarshalManyPayload except it just returns the
 // payload and doesn't write out results. Useful is you use your JSON rendering
 // library.
-func MarshalMany(models []interface{}) (*ManyPayload, error) {
+func MarshalMany(models []interface{}, opts ...MarshalOption) (*ManyPayload, error) {
 	payload := &ManyPayload{
 		Data: []*Node{},
 	}
 	included := map[string]*Node{}
+	options := newMarshalOptions(opts)
 
 	for _, model := range models {
-		node, err := visitModelNode(model, &included, true)
+		node, err := visitModelNode(model, &included, true, options)
 		if err != nil {
 			return nil, err
 		}
@@ -143,6 +231,10 @@ func MarshalMany(models []interface{}) (*ManyPayload, error) {
 	}
 	payload.Included = nodeMapValues(&included)
 
+	if err := validatePayload(payload, options); err != nil {
+		return nil, err
+	}
+
 	return payload, nil
 }
 
@@ -159,13 +251,19 @@ func MarshalMany(models []interface{}) (*ManyPayload, error) {
 // produced by the client.  This is what this method is intended for.
 //
 // model interface{} should be a pointer to a struct.
-func MarshalOnePayloadEmbedded(w io.Writer, model interface{}) error {
-	rootNode, err := visitModelNode(model, nil, false)
+func MarshalOnePayloadEmbedded(w io.Writer, model interface{},
+	opts ...MarshalOption) error {
+	options := newMarshalOptions(opts)
+
+	rootNode, err := visitModelNode(model, nil, false, options)
 	if err != nil {
 		return err
 	}
 
 	payload := &OnePayload{Data: rootNode}
+	if err := validatePayload(payload, options); err != nil {
+		return err
+	}
 
 	if err := json.NewEncoder(w).Encode(payload); err != nil {
 		return err
@@ -175,107 +273,61 @@ func MarshalOnePayloadEmbedded(w io.Writer, model interface{}) error {
 }
 
 func visitModelNode(model interface{}, included *map[string]*Node,
-	sideload bool) (*Node, error) {
+	sideload bool, opts *marshalOptions) (*Node, error) {
 	node := new(Node)
 
 	var er error
 
 	modelValue := reflect.ValueOf(model).Elem()
-	modelType := reflect.ValueOf(model).Type().Elem()
 
-	for i := 0; i < modelValue.NumField(); i++ {
-		structField := modelValue.Type().Field(i)
-		tag := structField.Tag.Get(annotationJSONAPI)
-		if tag == "" {
-			continue
-		}
-
-		fieldValue := modelValue.Field(i)
-		fieldType := modelType.Field(i)
-
-		args := strings.Split(tag, annotationSeperator)
-
-		if len(args) < 1 {
-			er = ErrBadJSONAPIStructTag
-			break
-		}
+	schema, err := schemaOf(modelValue.Type())
+	if err != nil {
+		return nil, err
+	}
 
-		annotation := args[0]
+	fieldset := opts.fieldset(schema.typ)
 
-		if (annotation == annotationClientID && len(args) != 1) ||
-			(annotation != annotationClientID && len(args) < 2) {
-			er = ErrBadJSONAPIStructTag
-			break
-		}
+	for _, field := range schema.fields {
+		fieldValue := modelValue.Field(field.index)
+		annotation := field.annotation
 
 		if annotation == annotationPrimary {
-			v := fieldValue
-
-			// Deal with PTRS
-			var kind reflect.Kind
-			if fieldValue.Kind() == reflect.Ptr {
-				kind = fieldType.Type.Elem().Kind()
-				v = reflect.Indirect(fieldValue)
-			} else {
-				kind = fieldType.Type.Kind()
-			}
-
-			// Handle allowed types
-			switch kind {
-			case reflect.String:
-				node.ID = v.Interface().(string)
-			case reflect.Int:
-				node.ID = strconv.FormatInt(int64(v.Interface().(int)), 10)
-			case reflect.Int8:
-				node.ID = strconv.FormatInt(int64(v.Interface().(int8)), 10)
-			case reflect.Int16:
-				node.ID = strconv.FormatInt(int64(v.Interface().(int16)), 10)
-			case reflect.Int32:
-				node.ID = strconv.FormatInt(int64(v.Interface().(int32)), 10)
-			case reflect.Int64:
-				node.ID = strconv.FormatInt(v.Interface().(int64), 10)
-			case reflect.Uint:
-				node.ID = strconv.FormatUint(uint64(v.Interface().(uint)), 10)
-			case reflect.Uint8:
-				node.ID = strconv.FormatUint(uint64(v.Interface().(uint8)), 10)
-			case reflect.Uint16:
-				node.ID = strconv.FormatUint(uint64(v.Interface().(uint16)), 10)
-			case reflect.Uint32:
-				node.ID = strconv.FormatUint(uint64(v.Interface().(uint32)), 10)
-			case reflect.Uint64:
-				node.ID = strconv.FormatUint(v.Interface().(uint64), 10)
-			default:
-				// We had a JSON float (numeric), but our field was not one of the
-				// allowed numeric types
-				er = ErrBadJSONAPIID
+			id, err := marshalID(fieldValue)
+			if err != nil {
+				er = err
 				break
 			}
 
-			node.Type = args[1]
+			node.ID = id
+			node.Type = field.name
 		} else if annotation == annotationClientID {
 			clientID := fieldValue.String()
 			if clientID != "" {
 				node.ClientID = clientID
 			}
 		} else if annotation == annotationAttribute {
-			var omitEmpty, iso8601 bool
-
-			if len(args) > 2 {
-				for _, arg := range args[2:] {
-					switch arg {
-					case annotationOmitEmpty:
-						omitEmpty = true
-					case annotationISO8601:
-						iso8601 = true
-					}
-				}
+			if fieldset != nil && !fieldset[field.name] {
+				continue
 			}
 
+			omitEmpty, iso8601 := field.omitEmpty, field.iso8601
+
 			if node.Attributes == nil {
 				node.Attributes = make(map[string]interface{})
 			}
 
-			if fieldValue.Type() == reflect.TypeOf(time.Time{}) {
+			if value, ok, err := marshalCustomAttribute(fieldValue); ok {
+				if err != nil {
+					er = err
+					break
+				}
+
+				if omitEmpty && isEmptyValue(fieldValue) {
+					continue
+				}
+
+				node.Attributes[field.name] = value
+			} else if fieldValue.Type() == reflect.TypeOf(time.Time{}) {
 				t := fieldValue.Interface().(time.Time)
 
 				if t.IsZero() {
@@ -283,9 +335,9 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 				}
 
 				if iso8601 {
-					node.Attributes[args[1]] = t.UTC().Format(iso8601TimeFormat)
+					node.Attributes[field.name] = t.UTC().Format(iso8601TimeFormat)
 				} else {
-					node.Attributes[args[1]] = t.Unix()
+					node.Attributes[field.name] = t.Unix()
 				}
 			} else if fieldValue.Type() == reflect.TypeOf(new(time.Time)) {
 				// A time pointer may be nil
@@ -294,7 +346,7 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 						continue
 					}
 
-					node.Attributes[args[1]] = nil
+					node.Attributes[field.name] = nil
 				} else {
 					tm := fieldValue.Interface().(*time.Time)
 
@@ -303,85 +355,118 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 					}
 
 					if iso8601 {
-						node.Attributes[args[1]] = tm.UTC().Format(iso8601TimeFormat)
+						node.Attributes[field.name] = tm.UTC().Format(iso8601TimeFormat)
 					} else {
-						node.Attributes[args[1]] = tm.Unix()
+						node.Attributes[field.name] = tm.Unix()
 					}
 				}
 			} else {
 				// Dealing with a fieldValue that is not a time
-				emptyValue := reflect.Zero(fieldValue.Type())
 
 				// See if we need to omit this field
-				if omitEmpty && fieldValue.Interface() == emptyValue.Interface() {
+				if omitEmpty && isEmptyValue(fieldValue) {
 					continue
 				}
 
 				strAttr, ok := fieldValue.Interface().(string)
 				if ok {
-					node.Attributes[args[1]] = strAttr
+					node.Attributes[field.name] = strAttr
 				} else {
-					node.Attributes[args[1]] = fieldValue.Interface()
+					node.Attributes[field.name] = fieldValue.Interface()
 				}
 			}
 		} else if annotation == annotationRelation {
-			var omitEmpty bool
-
-			//add support for 'omitempty' struct tag for marshaling as absent
-			if len(args) > 2 {
-				omitEmpty = args[2] == annotationOmitEmpty
+			// A relationship left out of the fieldset is still traversed
+			// when its records were requested with WithInclude, but only to
+			// sideload them
+			hidden := fieldset != nil && !fieldset[field.name]
+			if hidden && (!sideload || !opts.includes(field.name) || field.identifiers()) {
+				continue
 			}
 
-			isSlice := fieldValue.Type().Kind() == reflect.Slice
-			if omitEmpty &&
+			isSlice := field.toMany
+			if field.omitEmpty &&
 				(isSlice && fieldValue.Len() < 1 ||
 					(!isSlice && fieldValue.IsNil())) {
 				continue
 			}
 
-			if node.Relationships == nil {
-				node.Relationships = make(map[string]interface{})
+			fieldName := field.name
+			setRelationship := func(relationship interface{}) {
+				if hidden {
+					return
+				}
+				if node.Relationships == nil {
+					node.Relationships = make(map[string]interface{})
+				}
+				node.Relationships[fieldName] = relationship
 			}
 
 			var relLinks *Links
 			if linkableModel, ok := model.(RelationshipLinkable); ok {
-				relLinks = linkableModel.JSONAPIRelationshipLinks(args[1])
+				relLinks = linkableModel.JSONAPIRelationshipLinks(field.name)
+			}
+
+			var relMeta *Meta
+			if metableModel, ok := model.(RelationshipMetable); ok {
+				relMeta = metableModel.JSONAPIRelationshipMeta(field.name)
+			}
+
+			if field.identifiers() {
+				setRelationship(identifierRelationship(
+					fieldValue,
+					isSlice,
+					relLinks,
+					relMeta,
+				))
+				continue
 			}
 
+			relOpts := opts.descend(field.name)
+
 			if isSlice {
 				// to-many relationship
 				relationship, err := visitModelNodeRelationships(
-					args[1],
+					field.name,
 					fieldValue,
 					included,
 					sideload,
+					relOpts,
 				)
 				if err != nil {
 					er = err
 					break
 				}
 				relationship.Links = relLinks
+				relationship.Meta = relMeta
 
 				if sideload {
 					shallowNodes := []*Node{}
 					for _, n := range relationship.Data {
-						appendIncluded(included, n)
+						if !relOpts.linkageOnly {
+							appendIncluded(included, n)
+						}
 						shallowNodes = append(shallowNodes, toShallowNode(n))
 					}
 
-					node.Relationships[args[1]] = &RelationshipManyNode{
+					setRelationship(&RelationshipManyNode{
 						Data:  shallowNodes,
 						Links: relationship.Links,
-					}
+						Meta:  relationship.Meta,
+					})
 				} else {
-					node.Relationships[args[1]] = relationship
+					setRelationship(relationship)
 				}
 			} else {
 				// to-one relationships
 
 				// Handle null relationship case
 				if fieldValue.IsNil() {
-					node.Relationships[args[1]] = &RelationshipOneNode{Data: nil}
+					setRelationship(&RelationshipOneNode{
+						Data:  nil,
+						Links: relLinks,
+						Meta:  relMeta,
+					})
 					continue
 				}
 
@@ -389,6 +474,7 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 					fieldValue.Interface(),
 					included,
 					sideload,
+					relOpts,
 				)
 				if err != nil {
 					er = err
@@ -396,22 +482,22 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 				}
 
 				if sideload {
-					appendIncluded(included, relationship)
-					node.Relationships[args[1]] = &RelationshipOneNode{
+					if !relOpts.linkageOnly {
+						appendIncluded(included, relationship)
+					}
+					setRelationship(&RelationshipOneNode{
 						Data:  toShallowNode(relationship),
 						Links: relLinks,
-					}
+						Meta:  relMeta,
+					})
 				} else {
-					node.Relationships[args[1]] = &RelationshipOneNode{
+					setRelationship(&RelationshipOneNode{
 						Data:  relationship,
 						Links: relLinks,
-					}
+						Meta:  relMeta,
+					})
 				}
 			}
-
-		} else {
-			er = ErrBadJSONAPIStructTag
-			break
 		}
 	}
 
@@ -427,6 +513,10 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 		node.Links = linkableModel.JSONAPILinks()
 	}
 
+	if metableModel, ok := model.(Metable); ok {
+		node.Meta = metableModel.JSONAPIMeta()
+	}
+
 	return node, nil
 }
 
@@ -438,13 +528,14 @@ func toShallowNode(node *Node) *Node {
 }
 
 func visitModelNodeRelationships(relationName string, models reflect.Value,
-	included *map[string]*Node, sideload bool) (*RelationshipManyNode, error) {
+	included *map[string]*Node, sideload bool,
+	opts *marshalOptions) (*RelationshipManyNode, error) {
 	nodes := []*Node{}
 
 	for i := 0; i < models.Len(); i++ {
 		n := models.Index(i).Interface()
 
-		node, err := visitModelNode(n, included, sideload)
+		node, err := visitModelNode(n, included, sideload, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -455,6 +546,41 @@ func visitModelNodeRelationships(relationName string, models reflect.Value,
 	return &RelationshipManyNode{Data: nodes}, nil
 }
 
+// identifierRelationship returns the relationship object of a relation field
+// holding resource identifiers, which are never sideloaded.  Nil elements of
+// a slice are left out of the resource linkage, which cannot hold nulls.
+func identifierRelationship(fieldValue reflect.Value, isSlice bool,
+	links *Links, meta *Meta) interface{} {
+	if isSlice {
+		nodes := []*Node{}
+		for i := 0; i < fieldValue.Len(); i++ {
+			node := identifierNode(fieldValue.Index(i).Interface().(*ResourceIdentifier))
+			if node == nil {
+				continue
+			}
+
+			nodes = append(nodes, node)
+		}
+
+		return &RelationshipManyNode{Data: nodes, Links: links, Meta: meta}
+	}
+
+	return &RelationshipOneNode{
+		Data:  identifierNode(fieldValue.Interface().(*ResourceIdentifier)),
+		Links: links,
+		Meta:  meta,
+	}
+}
+
+// identifierNode returns the resource linkage of a resource identifier, or nil.
+func identifierNode(identifier *ResourceIdentifier) *Node {
+	if identifier == nil {
+		return nil
+	}
+
+	return &Node{Type: identifier.Type, ID: identifier.ID, Meta: identifier.Meta}
+}
+
 func appendIncluded(m *map[string]*Node, nodes ...*Node) {
 	included := *m
 
@@ -493,3 +619,85 @@ func convertToSliceInterface(i *interface{}) ([]interface{}, error) {
 	}
 	return response, nil
 }
+
+// marshalID formats the value of a primary field as a resource id.  Field
+// types implementing IDMarshaler or encoding.TextMarshaler are formatted with
+// those methods, strings and integers as is, and other types implementing
+// fmt.Stringer with their String method.  A nil pointer has an empty id.
+func marshalID(fieldValue reflect.Value) (string, error) {
+	if fieldValue.Kind() == reflect.Ptr && fieldValue.IsNil() {
+		return "", nil
+	}
+
+	ptr := fieldValue
+	if ptr.Kind() != reflect.Ptr && ptr.CanAddr() {
+		ptr = ptr.Addr()
+	}
+
+	switch m := ptr.Interface().(type) {
+	case IDMarshaler:
+		return m.MarshalJSONAPIID()
+	case encoding.TextMarshaler:
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+
+	v := reflect.Indirect(fieldValue)
+
+	// Handle allowed types
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	}
+
+	if m, ok := ptr.Interface().(fmt.Stringer); ok {
+		return m.String(), nil
+	}
+
+	return "", ErrBadJSONAPIID
+}
+
+// marshalCustomAttribute marshals attribute values implementing
+// AttributeMarshaler, json.Marshaler or encoding.TextMarshaler, in that
+// order, through their address when the method has a pointer receiver.  It
+// reports false for other values, times, which are formatted according to
+// the iso8601 option, and nil pointers.
+func marshalCustomAttribute(fieldValue reflect.Value) (interface{}, bool, error) {
+	if isTimeType(fieldValue.Type()) ||
+		(fieldValue.Kind() == reflect.Ptr && fieldValue.IsNil()) {
+		return nil, false, nil
+	}
+
+	if fieldValue.Kind() != reflect.Ptr && fieldValue.CanAddr() {
+		fieldValue = fieldValue.Addr()
+	}
+
+	switch m := fieldValue.Interface().(type) {
+	case AttributeMarshaler:
+		value, err := m.MarshalJSONAPIAttribute()
+		return value, true, err
+	case json.Marshaler:
+		value, err := m.MarshalJSON()
+		return json.RawMessage(value), true, err
+	case encoding.TextMarshaler:
+		value, err := m.MarshalText()
+		return string(value), true, err
+	}
+
+	return nil, false, nil
+}
+
+// isEmptyValue reports whether an attribute value is empty for the purpose of
+// omitempty: a zero value, or a map or slice without elements.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		return v.Len() == 0
+	}
+
+	return v.IsZero()
+}
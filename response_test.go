@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -57,6 +58,36 @@ func (b *Blog) JSONAPIRelationshipLinks(relation string) *Links {
 	return nil
 }
 
+func (b *Blog) JSONAPIMeta() *Meta {
+	return &Meta{
+		"detail": "extra details regarding the blog",
+	}
+}
+
+func (b *Blog) JSONAPIRelationshipMeta(relation string) *Meta {
+	if relation == "posts" {
+		return &Meta{
+			"this": map[string]interface{}{
+				"can": map[string]interface{}{
+					"go": []interface{}{
+						"as",
+						"deep",
+						map[string]interface{}{
+							"as": "required",
+						},
+					},
+				},
+			},
+		}
+	}
+	if relation == "current_post" {
+		return &Meta{
+			"detail": "extra current_post detail",
+		}
+	}
+	return nil
+}
+
 type Post struct {
 	Blog
 	ID            uint64     `jsonapi:"primary,posts"`
@@ -519,6 +550,85 @@ func TestSupportsLinkable(t *testing.T) {
 	}
 }
 
+func TestSupportsMetable(t *testing.T) {
+	testModel := &Blog{
+		ID:        5,
+		Title:     "Title 1",
+		CreatedAt: time.Now(),
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalOnePayload(out, testModel); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(OnePayload)
+	if err := json.NewDecoder(out).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	data := resp.Data
+	if data.Meta == nil {
+		t.Fatalf("Expected data.meta")
+	}
+
+	meta := *data.Meta
+	if e, a := "extra details regarding the blog", meta["detail"]; e != a {
+		t.Fatalf("Was expecting meta.detail to be %q, got %q", e, a)
+	}
+}
+
+func TestRelationshipMeta(t *testing.T) {
+	testModel := testBlog()
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalOnePayload(out, testModel); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(OnePayload)
+	if err := json.NewDecoder(out).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	relations := resp.Data.Relationships
+
+	if relations["posts"].(map[string]interface{})["meta"] == nil {
+		t.Fatalf("Posts relationship meta were not materialized")
+	}
+
+	currentPost := relations["current_post"].(map[string]interface{})
+	currentPostMeta, ok := currentPost["meta"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Current post relationship meta were not materialized")
+	}
+	if e, a := "extra current_post detail", currentPostMeta["detail"]; e != a {
+		t.Fatalf("Was expecting meta.detail to be %q, got %q", e, a)
+	}
+}
+
+func TestMarshalManyTopLevelMeta(t *testing.T) {
+	payload, err := MarshalMany([]interface{}{testBlog()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload.Meta = &Meta{"total": 1}
+
+	out := bytes.NewBuffer(nil)
+	if err := json.NewEncoder(out).Encode(payload); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(ManyPayload)
+	if err := json.NewDecoder(out).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Meta == nil || (*resp.Meta)["total"] != float64(1) {
+		t.Fatalf("Was expecting the top-level meta to be present")
+	}
+}
+
 func TestInvalidLinkable(t *testing.T) {
 	testModel := &BadComment{
 		ID:   5,
@@ -627,6 +737,179 @@ func TestMarshalOnePayloadWithoutIncluded(t *testing.T) {
 	}
 }
 
+func TestMarshalComplexAttributes(t *testing.T) {
+	venue := &Venue{
+		ID:      "1",
+		Address: Address{Street: "1 Main St", City: "Springfield"},
+		Tags:    []Tag{{Name: "cosy", Score: 4}},
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalOnePayload(out, venue); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(OnePayload)
+	if err := json.NewDecoder(out).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"address": map[string]interface{}{"street": "1 Main St", "city": "Springfield"},
+		"tags":    []interface{}{map[string]interface{}{"name": "cosy", "score": float64(4)}},
+	}
+	if !reflect.DeepEqual(expected, resp.Data.Attributes) {
+		t.Fatalf("Was expecting attributes %v, got %v", expected, resp.Data.Attributes)
+	}
+}
+
+func TestMarshalCustomAttributes(t *testing.T) {
+	order := &Order{
+		ID:       "1",
+		Total:    1234,
+		Priority: 1,
+		Location: Point{3, 4},
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalOnePayload(out, order); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(OnePayload)
+	if err := json.NewDecoder(out).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"total":    "12.34",
+		"priority": "medium",
+		"location": []interface{}{float64(3), float64(4)},
+	}
+	if !reflect.DeepEqual(expected, resp.Data.Attributes) {
+		t.Fatalf("Was expecting attributes %v, got %v", expected, resp.Data.Attributes)
+	}
+}
+
+func TestMarshalCustomIDs(t *testing.T) {
+	line := &OrderLine{
+		Key:     LineKey{"abc", 2},
+		Product: &Product{ID: UUID{0xff}, Sku: "SKU-1"},
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalOnePayload(out, line); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(OnePayload)
+	if err := json.NewDecoder(out).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := "abc-2", resp.Data.ID; e != a {
+		t.Fatalf("Was expecting the id %s, got %s", e, a)
+	}
+	if e, a := "ff000000000000000000000000000000", resp.Included[0].ID; e != a {
+		t.Fatalf("Was expecting the product id %s, got %s", e, a)
+	}
+
+	out.Reset()
+	if err := MarshalOnePayload(out, &SkuProduct{ID: "SKU-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"id":"SKU-1"`) {
+		t.Fatalf("Was expecting the named string id, got %s", out.String())
+	}
+}
+
+func TestMarshalPolymorphicRelationships(t *testing.T) {
+	photo := &Photo{ID: 1, URL: "a.png"}
+	message := &Message{
+		ID:          1,
+		Pinned:      &Video{ID: 2, URL: "b.mp4", Thumbnail: photo},
+		Attachments: []Attachment{photo},
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalOnePayload(out, message); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(OnePayload)
+	if err := json.NewDecoder(out).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	pinned := resp.Data.Relationships["pinned"].(map[string]interface{})["data"]
+	if e, a := map[string]interface{}{"type": "videos", "id": "2"}, pinned; !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting the pinned linkage %v, got %v", e, a)
+	}
+	attachments := resp.Data.Relationships["attachments"].(map[string]interface{})["data"]
+	if e, a := []interface{}{map[string]interface{}{"type": "photos", "id": "1"}}, attachments; !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting the attachments linkage %v, got %v", e, a)
+	}
+	if e, a := []string{"photos,1", "videos,2"}, includedKeys(resp.Included); !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting included %v, got %v", e, a)
+	}
+}
+
+func TestMarshalResourceIdentifiers(t *testing.T) {
+	playlist := &Playlist{
+		ID:   1,
+		Name: "Focus",
+		Tracks: []*ResourceIdentifier{
+			{Type: "tracks", ID: "1", Meta: &Meta{"position": 1}},
+		},
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalOnePayload(out, playlist); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(OnePayload)
+	if err := json.NewDecoder(out).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"owner": map[string]interface{}{"data": nil},
+		"tracks": map[string]interface{}{
+			"data": []interface{}{
+				map[string]interface{}{
+					"type": "tracks",
+					"id":   "1",
+					"meta": map[string]interface{}{"position": float64(1)},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(expected, resp.Data.Relationships) {
+		t.Fatalf("Was expecting relationships %v, got %v", expected, resp.Data.Relationships)
+	}
+	if resp.Included != nil {
+		t.Fatalf("Was not expecting resource identifiers to be included, got %v", resp.Included)
+	}
+}
+
+func TestMarshalResourceIdentifiersSkipsNil(t *testing.T) {
+	playlist := &Playlist{
+		ID:     1,
+		Tracks: []*ResourceIdentifier{nil, {Type: "tracks", ID: "1"}, nil},
+	}
+
+	payload, err := MarshalOne(playlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracks := payload.Data.Relationships["tracks"].(*RelationshipManyNode)
+	if len(tracks.Data) != 1 || tracks.Data[0] == nil || tracks.Data[0].ID != "1" {
+		t.Fatalf("Was expecting the nil tracks to be left out, got %v", tracks.Data)
+	}
+}
+
 func TestMarshalMany(t *testing.T) {
 	data := []interface{}{
 		&Blog{
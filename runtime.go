@@ -45,36 +45,36 @@ func (r *Runtime) shouldInstrument() bool {
 	return Instrumentation != nil
 }
 
-func (r *Runtime) UnmarshalPayload(reader io.Reader, model interface{}) error {
+func (r *Runtime) UnmarshalPayload(reader io.Reader, model interface{}, opts ...UnmarshalOption) error {
 	return r.instrumentCall(UnmarshalStart, UnmarshalStop, func() error {
-		return UnmarshalPayload(reader, model)
+		return UnmarshalPayload(reader, model, opts...)
 	})
 }
 
-func (r *Runtime) UnmarshalManyPayload(reader io.Reader, kind reflect.Type) (elems []interface{}, err error) {
+func (r *Runtime) UnmarshalManyPayload(reader io.Reader, kind reflect.Type, opts ...UnmarshalOption) (elems []interface{}, err error) {
 	r.instrumentCall(UnmarshalStart, UnmarshalStop, func() error {
-		elems, err = UnmarshalManyPayload(reader, kind)
+		elems, err = UnmarshalManyPayload(reader, kind, opts...)
 		return err
 	})
 
 	return
 }
 
-func (r *Runtime) MarshalOnePayload(w io.Writer, model interface{}) error {
+func (r *Runtime) MarshalOnePayload(w io.Writer, model interface{}, opts ...MarshalOption) error {
 	return r.instrumentCall(MarshalStart, MarshalStop, func() error {
-		return MarshalOnePayload(w, model)
+		return MarshalOnePayload(w, model, opts...)
 	})
 }
 
-func (r *Runtime) MarshalManyPayload(w io.Writer, models interface{}) error {
+func (r *Runtime) MarshalManyPayload(w io.Writer, models interface{}, opts ...MarshalOption) error {
 	return r.instrumentCall(MarshalStart, MarshalStop, func() error {
-		return MarshalManyPayload(w, models)
+		return MarshalManyPayload(w, models, opts...)
 	})
 }
 
-func (r *Runtime) MarshalOnePayloadEmbedded(w io.Writer, model interface{}) error {
+func (r *Runtime) MarshalOnePayloadEmbedded(w io.Writer, model interface{}, opts ...MarshalOption) error {
 	return r.instrumentCall(MarshalStart, MarshalStop, func() error {
-		return MarshalOnePayloadEmbedded(w, model)
+		return MarshalOnePayloadEmbedded(w, model, opts...)
 	})
 }
 
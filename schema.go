@@ -0,0 +1,213 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// modelSchema is the jsonapi struct tag metadata of a model struct type,
+// parsed once and cached by schemaOf.
+type modelSchema struct {
+	// modelType is the struct type described by the schema
+	modelType reflect.Type
+	// typ is the JSON API type given by the primary annotation
+	typ string
+	// primary is the field annotated as primary, if any
+	primary *fieldSchema
+	// fields are all of the annotated fields, in struct order
+	fields []*fieldSchema
+	// attributes indexes the attr fields by attribute name
+	attributes map[string]*fieldSchema
+	// relations indexes the relation fields by relationship name
+	relations map[string]*fieldSchema
+	// sortable is set when some attributes have the "sortable" option,
+	// restricting sorting to them
+	sortable bool
+	// err is the first error found parsing the struct tags, if any; it makes
+	// the type unusable for marshaling and unmarshaling
+	err error
+	// problems lists every problem found parsing the struct tags, including
+	// those that are tolerated when marshaling and unmarshaling
+	problems []*TagError
+}
+
+// fieldSchema is the parsed jsonapi struct tag of a single field.
+type fieldSchema struct {
+	// index is the index of the field in its struct
+	index int
+	// structField is the reflected field
+	structField reflect.StructField
+	// annotation is the first argument of the tag, e.g. "attr"
+	annotation string
+	// name is the second argument of the tag: the type for a primary field,
+	// the attribute or relationship name otherwise
+	name string
+	// omitEmpty is set by the "omitempty" option
+	omitEmpty bool
+	// iso8601 is set by the "iso8601" option
+	iso8601 bool
+	// sortable is set by the "sortable" option
+	sortable bool
+	// toMany is set for relation fields holding a slice of records
+	toMany bool
+}
+
+// schemaCache maps a model struct type to its *modelSchema.
+var schemaCache sync.Map
+
+// schemaOf returns the schema of the given model struct type, parsing its
+// struct tags the first time the type is seen.
+func schemaOf(modelType reflect.Type) (*modelSchema, error) {
+	if cached, ok := schemaCache.Load(modelType); ok {
+		schema := cached.(*modelSchema)
+		return schema, schema.err
+	}
+
+	cached, _ := schemaCache.LoadOrStore(modelType, parseSchema(modelType))
+	schema := cached.(*modelSchema)
+
+	return schema, schema.err
+}
+
+// parseSchema parses the jsonapi struct tags of the given model struct type.
+func parseSchema(modelType reflect.Type) *modelSchema {
+	schema := &modelSchema{
+		modelType:  modelType,
+		attributes: make(map[string]*fieldSchema),
+		relations:  make(map[string]*fieldSchema),
+	}
+
+	for i := 0; i < modelType.NumField(); i++ {
+		structField := modelType.Field(i)
+		tag := structField.Tag.Get(annotationJSONAPI)
+		if tag == "" {
+			continue
+		}
+
+		args := strings.Split(tag, annotationSeperator)
+		annotation := args[0]
+
+		if (annotation == annotationClientID && len(args) != 1) ||
+			(annotation != annotationClientID && len(args) < 2) {
+			schema.fail(structField, ErrBadJSONAPIStructTag,
+				fmt.Errorf("%w: %q", ErrBadJSONAPIStructTag, tag))
+			continue
+		}
+
+		field := &fieldSchema{
+			index:       i,
+			structField: structField,
+			annotation:  annotation,
+		}
+		if len(args) > 1 {
+			field.name = args[1]
+		}
+
+		switch annotation {
+		case annotationPrimary:
+			for _, arg := range args[2:] {
+				schema.problem(structField, fmt.Errorf("unknown primary option %q", arg))
+			}
+
+			schema.primary = field
+			schema.typ = field.name
+		case annotationClientID:
+		case annotationAttribute:
+			for _, arg := range args[2:] {
+				switch arg {
+				case annotationOmitEmpty:
+					field.omitEmpty = true
+				case annotationISO8601:
+					field.iso8601 = true
+				case annotationSortable:
+					field.sortable = true
+					schema.sortable = true
+				default:
+					schema.problem(structField, fmt.Errorf("unknown attr option %q", arg))
+				}
+			}
+
+			schema.attributes[field.name] = field
+		case annotationRelation:
+			//add support for 'omitempty' struct tag for marshaling as absent
+			if len(args) > 2 {
+				field.omitEmpty = args[2] == annotationOmitEmpty
+			}
+			for _, arg := range args[2:] {
+				if arg != annotationOmitEmpty {
+					schema.problem(structField, fmt.Errorf("unknown relation option %q", arg))
+				}
+			}
+			field.toMany = structField.Type.Kind() == reflect.Slice
+
+			schema.relations[field.name] = field
+		default:
+			err := fmt.Errorf(unsuportedStructTagMsg, annotation)
+			schema.fail(structField, err, err)
+			continue
+		}
+
+		schema.fields = append(schema.fields, field)
+	}
+
+	return schema
+}
+
+// fail records a problem with the struct tag of the given field that makes
+// the type unusable; err is returned when marshaling and unmarshaling, while
+// detail is reported by Validate.
+func (s *modelSchema) fail(structField reflect.StructField, err, detail error) {
+	if s.err == nil {
+		s.err = err
+	}
+
+	s.problem(structField, detail)
+}
+
+// problem records a problem with the struct tag of the given field.
+func (s *modelSchema) problem(structField reflect.StructField, err error) {
+	s.problems = append(s.problems, &TagError{
+		Type:  s.modelType,
+		Field: structField.Name,
+		Err:   err,
+	})
+}
+
+// sortableBy reports whether records can be sorted by the attr field f: any
+// attribute can, unless some are tagged sortable.
+func (s *modelSchema) sortableBy(f *fieldSchema) bool {
+	return !s.sortable || f.sortable
+}
+
+// relatedType returns the struct type of the records held by a relation
+// field, dereferencing slices and pointers, or the interface type of a
+// polymorphic relation field.
+func (f *fieldSchema) relatedType() reflect.Type {
+	t := f.structField.Type
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// polymorphic reports whether a relation field holds records of any type
+// implementing an interface.
+func (f *fieldSchema) polymorphic() bool {
+	return f.relatedType().Kind() == reflect.Interface
+}
+
+// resourceIdentifierType is the type of the records of relation fields
+// holding resource linkage only.
+var resourceIdentifierType = reflect.TypeOf(ResourceIdentifier{})
+
+// identifiers reports whether a relation field holds resource identifiers
+// rather than records.
+func (f *fieldSchema) identifiers() bool {
+	return f.relatedType() == resourceIdentifierType
+}
@@ -0,0 +1,125 @@
+package jsonapi
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSchemaOfParsesTags(t *testing.T) {
+	schema, err := schemaOf(reflect.TypeOf(Blog{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := "blogs", schema.typ; e != a {
+		t.Fatalf("Was expecting the type to be %q, got %q", e, a)
+	}
+	if e, a := "ID", schema.primary.structField.Name; e != a {
+		t.Fatalf("Was expecting the primary field to be %s, got %s", e, a)
+	}
+	if len(schema.attributes) != 4 || len(schema.relations) != 2 {
+		t.Fatalf("Was expecting 4 attributes and 2 relations, got %d and %d",
+			len(schema.attributes), len(schema.relations))
+	}
+	if !schema.relations["posts"].toMany || schema.relations["current_post"].toMany {
+		t.Fatalf("Relationship cardinality was not parsed")
+	}
+
+	timestamps, err := schemaOf(reflect.TypeOf(Timestamp{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !timestamps.attributes["timestamp"].iso8601 {
+		t.Fatalf("Was expecting the iso8601 option to be parsed")
+	}
+
+	books, err := schemaOf(reflect.TypeOf(Book{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !books.attributes["title"].omitEmpty || books.attributes["author"].omitEmpty {
+		t.Fatalf("Was expecting the omitempty option to be parsed")
+	}
+}
+
+func TestSchemaOfIsCached(t *testing.T) {
+	var wg sync.WaitGroup
+	schemas := make([]*modelSchema, 10)
+
+	for i := range schemas {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			schemas[i], _ = schemaOf(reflect.TypeOf(Post{}))
+		}(i)
+	}
+	wg.Wait()
+
+	for _, schema := range schemas[1:] {
+		if schema != schemas[0] {
+			t.Fatal("Was expecting every caller to share the cached schema")
+		}
+	}
+}
+
+func TestSchemaOfBadTag(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		if _, err := schemaOf(reflect.TypeOf(BadModel{})); err != ErrBadJSONAPIStructTag {
+			t.Fatalf("Was expecting ErrBadJSONAPIStructTag, got %v", err)
+		}
+	}
+}
+
+func benchmarkBlogs(n int) []*Blog {
+	blogs := make([]*Blog, n)
+	for i := range blogs {
+		blogs[i] = testBlog()
+		blogs[i].ID = i
+	}
+
+	return blogs
+}
+
+func BenchmarkSchemaOf(b *testing.B) {
+	t := reflect.TypeOf(Blog{})
+	for i := 0; i < b.N; i++ {
+		schemaOf(t)
+	}
+}
+
+func BenchmarkParseSchema(b *testing.B) {
+	t := reflect.TypeOf(Blog{})
+	for i := 0; i < b.N; i++ {
+		parseSchema(t)
+	}
+}
+
+func BenchmarkMarshalManyPayload(b *testing.B) {
+	blogs := benchmarkBlogs(1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := MarshalManyPayload(ioutil.Discard, blogs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalManyPayload(b *testing.B) {
+	out := bytes.NewBuffer(nil)
+	if err := MarshalManyPayload(out, benchmarkBlogs(1000)); err != nil {
+		b.Fatal(err)
+	}
+	payload := out.Bytes()
+	blogType := reflect.TypeOf(new(Blog))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := UnmarshalManyPayload(bytes.NewReader(payload), blogType); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
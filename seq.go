@@ -0,0 +1,53 @@
+//go:build go1.23
+
+package jsonapi
+
+import (
+	"errors"
+	"io"
+	"iter"
+)
+
+// MarshalSeq writes a jsonapi response with many records, yielded by models,
+// using an Encoder, e.g.
+//
+//	jsonapi.MarshalSeq(w, store.AllBlogs()) // iter.Seq[*Blog]
+//
+// On error, MarshalSeq stops the iteration.
+func MarshalSeq[T any](w io.Writer, models iter.Seq[*T], opts ...MarshalOption) error {
+	enc := NewEncoder(w, opts...)
+
+	for model := range models {
+		if err := enc.Encode(model); err != nil {
+			return err
+		}
+	}
+
+	return enc.Close()
+}
+
+// UnmarshalSeq returns an iterator over the records of type T of a jsonapi
+// request, unmarshaled one at a time, see Decoder.  The iteration ends after
+// yielding the first error, e.g.
+//
+//	for blog, err := range jsonapi.UnmarshalSeq[Blog](r.Body) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func UnmarshalSeq[T any](in io.Reader, opts ...UnmarshalOption) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		stopped := errors.New("stopped")
+
+		err := UnmarshalEach(in, func(model *T) error {
+			if !yield(model, nil) {
+				return stopped
+			}
+			return nil
+		}, opts...)
+		if err != nil && err != stopped {
+			yield(nil, err)
+		}
+	}
+}
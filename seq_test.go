@@ -0,0 +1,41 @@
+//go:build go1.23
+
+package jsonapi
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMarshalSeq(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := MarshalSeq(out, slices.Values(benchmarkBlogs(2))); err != nil {
+		t.Fatal(err)
+	}
+
+	blogs, err := UnmarshalMany[Blog](out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blogs) != 2 || blogs[1].ID != 1 {
+		t.Fatalf("Was expecting the 2 blogs back, got %v", blogs)
+	}
+}
+
+func TestUnmarshalSeq(t *testing.T) {
+	var ids []int
+	for video, err := range UnmarshalSeq[Video](strings.NewReader(streamedVideos)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, video.ID)
+		break
+	}
+
+	if e := []int{1}; !reflect.DeepEqual(e, ids) {
+		t.Fatalf("Was expecting %v, got %v", e, ids)
+	}
+}
@@ -0,0 +1,140 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// SortField is a field requested with the "sort" query parameter.
+type SortField struct {
+	// Name is the name of an attribute, or a dot-separated path to an
+	// attribute of a related resource, e.g. "author.name".
+	Name string
+	// Desc is set when the field is prefixed with a minus, for descending
+	// order.
+	Desc bool
+}
+
+// ParseSort reads the fields requested with the "sort" query parameter, e.g.
+// "sort=-created_at,title", in order, checking each of them against the tags
+// of model, which should be a pointer to a struct.  A field names an
+// attribute, or follows to-one relationships to an attribute of a related
+// resource, e.g. "current_post.title".
+//
+// Every attribute can be sorted by, unless some attributes of a model are
+// tagged with the "sortable" option, in which case only those can:
+//
+//	Title string `jsonapi:"attr,title,sortable"`
+//
+// ParseSort returns nil when there is no sort parameter, and a 400 Bad
+// Request *ErrorObject when a field cannot be sorted by.
+//
+// http://jsonapi.org/format/#fetching-sorting
+func ParseSort(query url.Values, model interface{}) ([]SortField, error) {
+	values, ok := query[QueryParamSort]
+	if !ok {
+		return nil, nil
+	}
+
+	modelType := reflect.TypeOf(model)
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+
+	fields := []SortField{}
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+
+			field := SortField{Name: name}
+			if strings.HasPrefix(name, "-") {
+				field.Name, field.Desc = name[1:], true
+			}
+
+			if err := checkSortField(modelType, field.Name, field.Name); err != nil {
+				return nil, err
+			}
+
+			fields = append(fields, field)
+		}
+	}
+
+	return fields, nil
+}
+
+// checkSortField ensures that path, the rest of the sort field name, leads
+// from modelType to a sortable attribute through to-one relationships.  Past
+// a polymorphic relationship, the rest of the path should be valid for one of
+// the registered types implementing its interface.
+func checkSortField(modelType reflect.Type, path, name string) error {
+	schema, err := schemaOf(modelType)
+	if err != nil {
+		return err
+	}
+
+	dot := strings.Index(path, ".")
+	if dot < 0 {
+		if field, ok := schema.attributes[path]; ok && schema.sortableBy(field) {
+			return nil
+		}
+
+		return invalidSortField(fmt.Sprintf(
+			"%s is not a sortable attribute of %s, in sort field %s",
+			path,
+			schema.typ,
+			name,
+		))
+	}
+
+	relation, rest := path[:dot], path[dot+1:]
+
+	field, ok := schema.relations[relation]
+	if !ok || field.identifiers() {
+		return invalidSortField(fmt.Sprintf(
+			"%s is not a relationship of %s, in sort field %s",
+			relation,
+			schema.typ,
+			name,
+		))
+	}
+	if field.toMany {
+		return invalidSortField(fmt.Sprintf(
+			"%s is a to-many relationship of %s, in sort field %s",
+			relation,
+			schema.typ,
+			name,
+		))
+	}
+
+	if field.polymorphic() {
+		for _, implementation := range registeredImplementations(field.relatedType()) {
+			if checkSortField(implementation, rest, name) == nil {
+				return nil
+			}
+		}
+
+		return invalidSortField(fmt.Sprintf(
+			"%s is not a sortable field of any resource of %s, in sort field %s",
+			rest,
+			relation,
+			name,
+		))
+	}
+
+	return checkSortField(field.relatedType(), rest, name)
+}
+
+// invalidSortField returns the error reported for a sort field that cannot be
+// sorted by.
+func invalidSortField(detail string) *ErrorObject {
+	return &ErrorObject{
+		Status: "400",
+		Title:  "Invalid Query Parameter",
+		Detail: detail,
+		Source: &ErrorSource{Parameter: QueryParamSort},
+	}
+}
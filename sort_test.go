@@ -0,0 +1,82 @@
+package jsonapi
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type SortableArticle struct {
+	ID        int    `jsonapi:"primary,articles"`
+	Title     string `jsonapi:"attr,title,sortable"`
+	Body      string `jsonapi:"attr,body"`
+	Published int64  `jsonapi:"attr,published,omitempty,sortable"`
+	Blog      *Blog  `jsonapi:"relation,blog"`
+}
+
+func TestParseSort(t *testing.T) {
+	query, _ := url.ParseQuery("sort=-created_at,title&sort=current_post.latest_comment.body")
+
+	fields, err := ParseSort(query, new(Blog))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SortField{
+		{Name: "created_at", Desc: true},
+		{Name: "title"},
+		{Name: "current_post.latest_comment.body"},
+	}
+	if !reflect.DeepEqual(expected, fields) {
+		t.Fatalf("Was expecting %v, got %v", expected, fields)
+	}
+
+	if fields, err := ParseSort(url.Values{}, new(Blog)); err != nil || fields != nil {
+		t.Fatalf("Was expecting no sort fields, got %v, %v", fields, err)
+	}
+}
+
+func TestParseSortSortableOption(t *testing.T) {
+	query := url.Values{QueryParamSort: {"-published,title,blog.view_count"}}
+	if _, err := ParseSort(query, new(SortableArticle)); err != nil {
+		t.Fatal(err)
+	}
+
+	query = url.Values{QueryParamSort: {"body"}}
+	if _, err := ParseSort(query, new(SortableArticle)); err == nil {
+		t.Fatal("Was expecting body not to be sortable")
+	}
+}
+
+func TestParseSortPolymorphic(t *testing.T) {
+	query := url.Values{QueryParamSort: {"pinned.url,pinned.thumbnail.url"}}
+	if _, err := ParseSort(query, new(Message)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseSortInvalid(t *testing.T) {
+	for _, test := range []struct {
+		model interface{}
+		value string
+	}{
+		{new(Blog), "subtitle"},
+		{new(Blog), "-"},
+		{new(Blog), "posts.title"},
+		{new(Blog), "current_post"},
+		{new(Blog), "current_post.author"},
+		{new(Blog), "author.name"},
+		{new(Message), "pinned.name"},
+		{new(Playlist), "owner.name"},
+	} {
+		_, err := ParseSort(url.Values{QueryParamSort: {test.value}}, test.model)
+
+		errorObject, ok := err.(*ErrorObject)
+		if !ok {
+			t.Fatalf("Was expecting an *ErrorObject for %s, got %v", test.value, err)
+		}
+		if errorObject.Status != "400" || errorObject.Source.Parameter != QueryParamSort {
+			t.Fatalf("Was expecting a 400 for the sort parameter, got %+v", errorObject)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// ErrEncoderClosed is returned when records are encoded after the Encoder was
+// closed.
+var ErrEncoderClosed = errors.New("Encoder is closed")
+
+// Encoder writes a jsonapi response with many records, like
+// MarshalManyPayload, one record at a time.  Each record is written to the
+// underlying writer as soon as it is encoded, while the related records to
+// sideload into the "included" array are collected, once each, and written
+// when the Encoder is closed.  This keeps the memory used to write very large
+// collections down to that of the included records, e.g.
+//
+//	enc := jsonapi.NewEncoder(w)
+//	for rows.Next() {
+//		blog, err := scanBlog(rows)
+//		...
+//		if err := enc.Encode(blog); err != nil {
+//			return err
+//		}
+//	}
+//	return enc.Close()
+//
+// The Encoder does not buffer its writes; wrap w in a bufio.Writer if needed.
+type Encoder struct {
+	w        io.Writer
+	opts     *marshalOptions
+	count    int
+	seen     map[string]bool
+	included []*Node
+	err      error
+	closed   bool
+}
+
+// NewEncoder returns an Encoder writing to w, applying opts to every record.
+func NewEncoder(w io.Writer, opts ...MarshalOption) *Encoder {
+	return &Encoder{
+		w:    w,
+		opts: newMarshalOptions(opts),
+		seen: make(map[string]bool),
+	}
+}
+
+// Encode writes model, which should be a pointer to a struct, as the next
+// element of the "data" array.  Once an error occurs, Encode keeps returning
+// it without writing anything.
+func (e *Encoder) Encode(model interface{}) error {
+	if e.closed {
+		return ErrEncoderClosed
+	}
+	if e.err != nil {
+		return e.err
+	}
+
+	included := make(map[string]*Node)
+
+	node, err := visitModelNode(model, &included, true, e.opts)
+	if err != nil {
+		e.err = err
+		return err
+	}
+
+	buf, err := json.Marshal(node)
+	if err != nil {
+		e.err = err
+		return err
+	}
+
+	prefix := ","
+	if e.count == 0 {
+		prefix = `{"data":[`
+	}
+
+	if err := e.write(prefix, buf); err != nil {
+		return err
+	}
+	e.count++
+
+	for key, n := range included {
+		if e.seen[key] {
+			continue
+		}
+
+		e.seen[key] = true
+		e.included = append(e.included, n)
+	}
+
+	return nil
+}
+
+// Close ends the "data" array, writes the "included" array, if any, and ends
+// the document.  It does not close the underlying writer.
+func (e *Encoder) Close() error {
+	if e.closed {
+		return ErrEncoderClosed
+	}
+	e.closed = true
+
+	if e.err != nil {
+		return e.err
+	}
+
+	if e.count == 0 {
+		if err := e.write(`{"data":[`, nil); err != nil {
+			return err
+		}
+	}
+
+	if err := e.write("]", nil); err != nil {
+		return err
+	}
+
+	for i, n := range e.included {
+		buf, err := json.Marshal(n)
+		if err != nil {
+			e.err = err
+			return err
+		}
+
+		prefix := ","
+		if i == 0 {
+			prefix = `,"included":[`
+		}
+
+		if err := e.write(prefix, buf); err != nil {
+			return err
+		}
+	}
+
+	if len(e.included) > 0 {
+		if err := e.write("]", nil); err != nil {
+			return err
+		}
+	}
+
+	return e.write("}\n", nil)
+}
+
+// write writes prefix followed by buf, recording any error.
+func (e *Encoder) write(prefix string, buf []byte) error {
+	if _, err := io.WriteString(e.w, prefix); err != nil {
+		e.err = err
+		return err
+	}
+
+	if _, err := e.w.Write(buf); err != nil {
+		e.err = err
+		return err
+	}
+
+	return nil
+}
+
+// Decoder reads a jsonapi document with many records, like
+// UnmarshalManyPayload, one record at a time, handing each record to a
+// callback as soon as it is unmarshaled rather than building a slice of them.
+//
+// Records can only be unmarshaled once the "included" array they may refer
+// to has been read.  When "included" comes before "data" in the document,
+// each record is unmarshaled while the "data" array is read; otherwise the
+// resource objects of "data" are held until the end of the document, which
+// takes as much memory as UnmarshalManyPayload.  For documents without
+// "included", the usual shape of bulk imports, the StreamData option
+// unmarshals each record as soon as it is read.
+type Decoder struct {
+	in   io.Reader
+	dec  *json.Decoder
+	opts *unmarshalOptions
+	meta *Meta
+}
+
+// ErrIncludedAfterData is returned by a Decoder with the StreamData option
+// when the "included" array of a document follows records of "data" that
+// were already unmarshaled without it.
+var ErrIncludedAfterData = errors.New(`"included" must come before "data" when streaming data`)
+
+// StreamData returns an UnmarshalOption with which a Decoder unmarshals each
+// record of "data" as soon as it is read, rather than holding the records
+// until the end of the document in case an "included" array follows them.
+// Records are unmarshaled with the "included" array only if it comes first;
+// one that follows records results in ErrIncludedAfterData.  Other functions
+// ignore the option.
+func StreamData() UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.streamData = true
+	}
+}
+
+// NewDecoder returns a Decoder reading from in, applying opts to every
+// record.  With the Strict or RequireID options, decoding stops at the first
+// record with violations.
+func NewDecoder(in io.Reader, opts ...UnmarshalOption) *Decoder {
+	return &Decoder{in: in, dec: json.NewDecoder(in), opts: newUnmarshalOptions(opts)}
+}
+
+// DecodeEach unmarshals each record of the document into a new instance of
+// t, which should be a pointer to a struct type, and calls fn with it, e.g.
+//
+//	dec := jsonapi.NewDecoder(r.Body)
+//	err := dec.DecodeEach(reflect.TypeOf(new(Blog)), func(model interface{}) error {
+//		return store.SaveBlog(model.(*Blog))
+//	})
+//
+// If t is nil, each record is unmarshaled into the model registered for its
+// type, as with UnmarshalMixedManyPayload.  Unless "included" comes first in
+// the document, fn is only called once all of it is read, see StreamData.
+// DecodeEach stops at the first error, including one returned by fn.  If the document is an errors
+// document, the returned error will be an *ErrorsPayload.
+func (d *Decoder) DecodeEach(t reflect.Type, fn func(model interface{}) error) error {
+	if d.opts.validate {
+		in, err := validateInput(d.in, d.opts)
+		if err != nil {
+			return err
+		}
+		d.dec = json.NewDecoder(in)
+	}
+
+	newModel := newRegisteredModel
+	if t != nil {
+		newModel = func(data *Node, pointer string) (reflect.Value, error) {
+			return reflect.New(t.Elem()), nil
+		}
+	}
+
+	var (
+		includedList  []*Node
+		included      includedNodes
+		includedFound bool
+		checker       *documentChecker
+		pending       []*Node
+		count         int
+		errorObjects  []*ErrorObject
+	)
+
+	unmarshal := func(data *Node) error {
+		pointer := fmt.Sprintf("/data/%d", count)
+		count++
+
+		if count == 1 {
+			checker = newDocumentChecker(d.opts, includedList, included)
+		}
+
+		model, err := newModel(data, pointer)
+		if err == nil {
+			if checker != nil {
+				checker.checkPrimary(data, model.Type(), pointer)
+			}
+
+			err = unmarshalNode(data, model, included, pointer)
+		}
+		if checker != nil {
+			err = checker.result(err)
+		}
+		if err != nil {
+			return err
+		}
+
+		return fn(model.Interface())
+	}
+
+	if err := d.expectDelim('{'); err != nil {
+		return err
+	}
+
+	for d.dec.More() {
+		key, err := d.dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case "data":
+			if err := d.expectArray("/data"); err != nil {
+				return err
+			}
+
+			for d.dec.More() {
+				data := new(Node)
+				if err := d.dec.Decode(&data); err != nil {
+					return err
+				}
+
+				if !includedFound && !d.opts.streamData {
+					pending = append(pending, data)
+					continue
+				}
+
+				if err := unmarshal(data); err != nil {
+					return err
+				}
+			}
+
+			if err := d.expectDelim(']'); err != nil {
+				return err
+			}
+		case "included":
+			if d.opts.streamData && count > 0 {
+				return ErrIncludedAfterData
+			}
+
+			if err := d.dec.Decode(&includedList); err != nil {
+				return err
+			}
+
+			included = newIncludedNodes(includedList)
+			includedFound = true
+		case "errors":
+			if err := d.dec.Decode(&errorObjects); err != nil {
+				return err
+			}
+		case "meta":
+			if err := d.dec.Decode(&d.meta); err != nil {
+				return err
+			}
+		default:
+			// Skip the members we have no use for, e.g. "links"
+			var skipped json.RawMessage
+			if err := d.dec.Decode(&skipped); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := d.expectDelim('}'); err != nil {
+		return err
+	}
+
+	if errorObjects != nil {
+		return &ErrorsPayload{Errors: errorObjects, Meta: d.meta}
+	}
+
+	for _, data := range pending {
+		if err := unmarshal(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Meta returns the top-level "meta" object of the document once it has been
+// read by DecodeEach, or nil if it had none.
+func (d *Decoder) Meta() *Meta {
+	return d.meta
+}
+
+// expectDelim reads the next token, expecting it to be delim.
+func (d *Decoder) expectDelim(delim json.Delim) error {
+	token, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if token != delim {
+		return fmt.Errorf("Expected %v in the document, got %v", delim, token)
+	}
+
+	return nil
+}
+
+// expectArray reads the next token, expecting it to start the array found at
+// pointer.
+func (d *Decoder) expectArray(pointer string) error {
+	token, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if token != json.Delim('[') {
+		return &UnmarshalError{
+			Pointer:  pointer,
+			JSONKind: tokenKind(token),
+			Err:      ErrInvalidType,
+		}
+	}
+
+	return nil
+}
+
+// tokenKind describes the kind of the JSON value starting with token the way
+// the JSON specification names it.
+func tokenKind(token json.Token) string {
+	switch token {
+	case json.Delim('{'):
+		return "object"
+	case json.Delim('['):
+		return "array"
+	}
+
+	return jsonKind(token)
+}
@@ -0,0 +1,247 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncoderMatchesMarshalManyPayload(t *testing.T) {
+	blogs := benchmarkBlogs(3)
+
+	expected := bytes.NewBuffer(nil)
+	if err := MarshalManyPayload(expected, blogs); err != nil {
+		t.Fatal(err)
+	}
+
+	out := bytes.NewBuffer(nil)
+	enc := NewEncoder(out)
+	for _, blog := range blogs {
+		if err := enc.Encode(blog); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	e, a := new(ManyPayload), new(ManyPayload)
+	if err := json.Unmarshal(expected.Bytes(), e); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(out.Bytes(), a); err != nil {
+		t.Fatalf("Was expecting valid JSON, got %s: %v", out.String(), err)
+	}
+
+	if !reflect.DeepEqual(e.Data, a.Data) {
+		t.Fatalf("Was expecting the same data as MarshalManyPayload")
+	}
+	if ek, ak := includedKeys(e.Included), includedKeys(a.Included); !reflect.DeepEqual(ek, ak) {
+		t.Fatalf("Was expecting included %v, got %v", ek, ak)
+	}
+}
+
+func TestEncoderWithoutRecords(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := NewEncoder(out).Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := "{\"data\":[]}\n", out.String(); e != a {
+		t.Fatalf("Was expecting %q, got %q", e, a)
+	}
+}
+
+func TestEncoderErrors(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	enc := NewEncoder(out)
+
+	if err := enc.Encode(new(BadModel)); err != ErrBadJSONAPIStructTag {
+		t.Fatalf("Was expecting ErrBadJSONAPIStructTag, got %v", err)
+	}
+	if err := enc.Encode(testBlog()); err != ErrBadJSONAPIStructTag {
+		t.Fatalf("Was expecting the error to stick, got %v", err)
+	}
+	if err := enc.Close(); err != ErrBadJSONAPIStructTag {
+		t.Fatalf("Was expecting Close to return the error, got %v", err)
+	}
+	if err := enc.Encode(testBlog()); !errors.Is(err, ErrEncoderClosed) {
+		t.Fatalf("Was expecting ErrEncoderClosed, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("Was expecting nothing to be written, got %s", out.String())
+	}
+}
+
+const streamedVideos = `{
+	"meta": {"total": 2},
+	"data": [
+		{
+			"type": "videos",
+			"id": "1",
+			"attributes": {"url": "a.mp4"},
+			"relationships": {"thumbnail": {"data": {"type": "photos", "id": "1"}}}
+		},
+		{"type": "videos", "id": "2", "attributes": {"url": "b.mp4"}}
+	],
+	"included": [
+		{"type": "photos", "id": "1", "attributes": {"url": "a.png"}}
+	]
+}`
+
+func TestDecoderBuffersDataBeforeIncluded(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(streamedVideos))
+
+	var videos []*Video
+	err := dec.DecodeEach(reflect.TypeOf(new(Video)), func(model interface{}) error {
+		videos = append(videos, model.(*Video))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*Video{
+		{ID: 1, URL: "a.mp4", Thumbnail: &Photo{ID: 1, URL: "a.png"}},
+		{ID: 2, URL: "b.mp4"},
+	}
+	if !reflect.DeepEqual(expected, videos) {
+		t.Fatalf("Was expecting %#v, got %#v", expected, videos)
+	}
+	if meta := dec.Meta(); meta == nil || (*meta)["total"] != float64(2) {
+		t.Fatalf("Was expecting the top-level meta, got %v", meta)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestDecoderStreamsDataAfterIncluded(t *testing.T) {
+	// The input fails after the first record, which should be handed over
+	// before the failure is reached
+	in := io.MultiReader(strings.NewReader(`{
+		"included": [{"type": "photos", "id": "1", "attributes": {"url": "a.png"}}],
+		"data": [
+			{
+				"type": "videos",
+				"id": "1",
+				"relationships": {"thumbnail": {"data": {"type": "photos", "id": "1"}}}
+			},`), failingReader{})
+
+	var videos []*Video
+	err := NewDecoder(in).DecodeEach(reflect.TypeOf(new(Video)), func(model interface{}) error {
+		videos = append(videos, model.(*Video))
+		return nil
+	})
+	if err == nil || err.Error() != "connection reset" {
+		t.Fatalf("Was expecting the read error, got %v", err)
+	}
+
+	if len(videos) != 1 || videos[0].Thumbnail == nil || videos[0].Thumbnail.URL != "a.png" {
+		t.Fatalf("Was expecting the first video with its thumbnail, got %#v", videos)
+	}
+}
+
+func TestDecoderStreamData(t *testing.T) {
+	// Without included, the first record should be handed over before the
+	// failure is reached
+	in := io.MultiReader(strings.NewReader(`{
+		"data": [
+			{"type": "videos", "id": "1", "attributes": {"url": "a.mp4"}},`), failingReader{})
+
+	var videos []*Video
+	err := NewDecoder(in, StreamData()).DecodeEach(reflect.TypeOf(new(Video)), func(model interface{}) error {
+		videos = append(videos, model.(*Video))
+		return nil
+	})
+	if err == nil || err.Error() != "connection reset" {
+		t.Fatalf("Was expecting the read error, got %v", err)
+	}
+	if len(videos) != 1 || videos[0].URL != "a.mp4" {
+		t.Fatalf("Was expecting the first video, got %#v", videos)
+	}
+
+	// Without the option, the records are held until the end
+	in = io.MultiReader(strings.NewReader(`{"data": [{"type": "videos", "id": "1"},`), failingReader{})
+
+	videos = nil
+	NewDecoder(in).DecodeEach(reflect.TypeOf(new(Video)), func(model interface{}) error {
+		videos = append(videos, model.(*Video))
+		return nil
+	})
+	if len(videos) != 0 {
+		t.Fatalf("Was expecting no video before the end of the document, got %#v", videos)
+	}
+
+	dec := NewDecoder(strings.NewReader(streamedVideos), StreamData())
+	if err := dec.DecodeEach(reflect.TypeOf(new(Video)), func(model interface{}) error {
+		return nil
+	}); err != ErrIncludedAfterData {
+		t.Fatalf("Was expecting ErrIncludedAfterData, got %v", err)
+	}
+}
+
+func TestDecoderRegisteredTypes(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": [
+			{"type": "photos", "id": "1"},
+			{"type": "videos", "id": "2"},
+			{"type": "audio", "id": "3"}
+		]
+	}`)
+
+	var models []interface{}
+	err := NewDecoder(in).DecodeEach(nil, func(model interface{}) error {
+		models = append(models, model)
+		return nil
+	})
+
+	if !errors.Is(err, ErrUnregisteredType) {
+		t.Fatalf("Was expecting ErrUnregisteredType, got %v", err)
+	}
+	if e, a := "/data/2/type", err.(*UnmarshalError).Pointer; e != a {
+		t.Fatalf("Was expecting the pointer %s, got %s", e, a)
+	}
+	if e := []interface{}{&Photo{ID: 1}, &Video{ID: 2}}; !reflect.DeepEqual(e, models) {
+		t.Fatalf("Was expecting %#v, got %#v", e, models)
+	}
+}
+
+func TestDecoderStopsOnCallbackError(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+
+	err := NewDecoder(strings.NewReader(streamedVideos)).DecodeEach(reflect.TypeOf(new(Video)), func(interface{}) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Fatalf("Was expecting to stop after the first record, got %v after %d calls", err, calls)
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	err := NewDecoder(strings.NewReader(`{
+		"errors": [{"status": "404", "title": "Not Found"}]
+	}`)).DecodeEach(reflect.TypeOf(new(Video)), func(interface{}) error {
+		t.Fatal("Was not expecting any record")
+		return nil
+	})
+	if errorsPayload, ok := err.(*ErrorsPayload); !ok || errorsPayload.Errors[0].Status != "404" {
+		t.Fatalf("Was expecting an *ErrorsPayload, got %v", err)
+	}
+
+	err = NewDecoder(strings.NewReader(`{"data": {"type": "videos", "id": "1"}}`)).
+		DecodeEach(reflect.TypeOf(new(Video)), func(interface{}) error { return nil })
+	if unmarshalError, ok := err.(*UnmarshalError); !ok ||
+		unmarshalError.Pointer != "/data" || unmarshalError.JSONKind != "object" {
+		t.Fatalf("Was expecting an *UnmarshalError for the /data object, got %v", err)
+	}
+}
@@ -0,0 +1,358 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrUnknownAttribute is returned in Strict mode when a resource object
+	// has an attribute that does not match the attr tags of its model.
+	ErrUnknownAttribute = errors.New("Unknown attribute")
+	// ErrTypeConflict is returned when the type of a resource object does not
+	// match the primary tag of its model; its error object has a 409 Conflict
+	// status.
+	ErrTypeConflict = errors.New("Resource type does not match")
+	// ErrMissingID is returned with the RequireID option when primary data
+	// has no id, and by UnmarshalRelationship for resource identifiers
+	// without one.
+	ErrMissingID = errors.New("Resource id is missing")
+	// ErrDuplicateResource is returned in Strict mode when the same resource
+	// appears twice in primary data, in the "included" array, or in the
+	// resource linkage of a relationship.
+	ErrDuplicateResource = errors.New("Resource appears more than once")
+)
+
+// UnmarshalOption configures how payloads are unmarshaled by the Unmarshal
+// functions, e.g. Strict.
+type UnmarshalOption func(*unmarshalOptions)
+
+// unmarshalOptions holds the configuration set by UnmarshalOption values.
+type unmarshalOptions struct {
+	// strict rejects unknown members, type conflicts and duplicate resources
+	strict bool
+	// requireID rejects primary data without an id
+	requireID bool
+	// validate is set by the ValidateInput option
+	validate bool
+	// streamData is set by the StreamData option
+	streamData bool
+}
+
+func newUnmarshalOptions(opts []UnmarshalOption) *unmarshalOptions {
+	o := new(unmarshalOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// Strict returns an UnmarshalOption rejecting payloads with
+//
+//   - attributes or relationships that do not match the tags of their model
+//   - resource objects whose type does not match their model, see
+//     ErrTypeConflict
+//   - the same resource appearing twice in primary data, in the "included"
+//     array, or in the resource linkage of a relationship
+//
+// Every violation found in the payload, along with the error that stopped
+// unmarshaling if any, is reported in the returned UnmarshalErrors.
+func Strict() UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.strict = true
+	}
+}
+
+// RequireID returns an UnmarshalOption rejecting primary data without an id,
+// e.g. for update requests.  Violations are reported in the returned
+// UnmarshalErrors.
+func RequireID() UnmarshalOption {
+	return func(o *unmarshalOptions) {
+		o.requireID = true
+	}
+}
+
+// UnmarshalErrors is returned when unmarshaling with the Strict or RequireID
+// options, listing every violation found in the payload.
+type UnmarshalErrors []*UnmarshalError
+
+// Error implements the `Error` interface.
+func (e UnmarshalErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Is reports whether any of the violations matches target, so that
+// errors.Is(err, ErrUnknownAttribute) works before Go 1.20 too.
+func (e UnmarshalErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first of the violations that matches target, see errors.As.
+func (e UnmarshalErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Unwrap returns the violations, for errors.Is and errors.As from Go 1.20.
+func (e UnmarshalErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// ErrorObjects converts the violations into error objects, see
+// UnmarshalError.ErrorObject, e.g. to be written with MarshalErrors.
+func (e UnmarshalErrors) ErrorObjects() []*ErrorObject {
+	errorObjects := make([]*ErrorObject, len(e))
+	for i, err := range e {
+		errorObjects[i] = err.ErrorObject()
+	}
+
+	return errorObjects
+}
+
+// documentChecker collects the violations of a payload for the Strict and
+// RequireID options.
+type documentChecker struct {
+	opts       *unmarshalOptions
+	included   includedNodes
+	checked    map[string]bool
+	primary    map[string]bool
+	violations UnmarshalErrors
+}
+
+// newDocumentChecker returns a documentChecker for a payload with the given
+// "included" array, or nil if the options require no checks.
+func newDocumentChecker(opts *unmarshalOptions, nodes []*Node,
+	included includedNodes) *documentChecker {
+	if !opts.strict && !opts.requireID {
+		return nil
+	}
+
+	c := &documentChecker{
+		opts:     opts,
+		included: included,
+		checked:  make(map[string]bool),
+		primary:  make(map[string]bool),
+	}
+
+	if opts.strict {
+		seen := make(map[string]bool)
+		for i, n := range nodes {
+			if n == nil {
+				continue
+			}
+
+			key := fmt.Sprintf("%s,%s", n.Type, n.ID)
+			if seen[key] {
+				c.violation(fmt.Sprintf("/included/%d", i), nil, n.Type, ErrDuplicateResource)
+			}
+			seen[key] = true
+		}
+	}
+
+	return c
+}
+
+// checkPrimary checks a resource object of primary data, unmarshaled into a
+// model of type modelType, a pointer to a struct.
+func (c *documentChecker) checkPrimary(data *Node, modelType reflect.Type, pointer string) {
+	if data == nil {
+		return
+	}
+
+	if c.opts.requireID && data.ID == "" {
+		c.violation(pointer, modelType, nil, ErrMissingID)
+	}
+
+	if c.opts.strict && data.ID != "" {
+		key := fmt.Sprintf("%s,%s", data.Type, data.ID)
+		if c.primary[key] {
+			c.violation(pointer, modelType, data.Type, ErrDuplicateResource)
+		}
+		c.primary[key] = true
+	}
+
+	c.checkNode(data, modelType.Elem(), pointer)
+}
+
+// checkNode checks the type and members of a resource object unmarshaled
+// into a model of struct type modelType, and of the included resources it
+// links to.
+func (c *documentChecker) checkNode(data *Node, modelType reflect.Type, pointer string) {
+	if !c.opts.strict {
+		return
+	}
+
+	schema, err := schemaOf(modelType)
+	if err != nil {
+		// Reported when unmarshaling
+		return
+	}
+
+	if data.Type != schema.typ {
+		c.violation(pointer+"/type", modelType, data.Type,
+			fmt.Errorf("%w: %q is not %q", ErrTypeConflict, data.Type, schema.typ))
+		return
+	}
+
+	for _, name := range sortedKeys(data.Attributes) {
+		if schema.attributes[name] == nil {
+			c.violation(pointer+"/attributes/"+escapePointer(name), modelType,
+				data.Attributes[name], ErrUnknownAttribute)
+		}
+	}
+
+	for _, name := range sortedKeys(data.Relationships) {
+		member := pointer + "/relationships/" + escapePointer(name)
+
+		field := schema.relations[name]
+		if field == nil {
+			c.violation(member, modelType, data.Relationships[name], ErrUnknownRelationship)
+			continue
+		}
+
+		c.checkLinkage(data.Relationships[name], field, member)
+	}
+}
+
+// checkLinkage checks the resource linkage of a relationship, found at
+// pointer, held by the given relation field.
+func (c *documentChecker) checkLinkage(relationship interface{}, field *fieldSchema,
+	pointer string) {
+	object, ok := relationship.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	var linkage []interface{}
+	var pointers []string
+
+	switch data := object["data"].(type) {
+	case []interface{}:
+		for i, n := range data {
+			linkage = append(linkage, n)
+			pointers = append(pointers, fmt.Sprintf("%s/data/%d", pointer, i))
+		}
+	case map[string]interface{}:
+		linkage = append(linkage, data)
+		pointers = append(pointers, pointer+"/data")
+	}
+
+	seen := make(map[string]bool)
+
+	for i, n := range linkage {
+		node := new(Node)
+		if buf, err := json.Marshal(n); err != nil || json.Unmarshal(buf, node) != nil {
+			// Reported when unmarshaling
+			continue
+		}
+
+		key := fmt.Sprintf("%s,%s", node.Type, node.ID)
+		if seen[key] {
+			c.violation(pointers[i], field.structField.Type, node.Type, ErrDuplicateResource)
+			continue
+		}
+		seen[key] = true
+
+		if field.identifiers() {
+			continue
+		}
+
+		relatedType := field.relatedType()
+		if field.polymorphic() {
+			var ok bool
+			if relatedType, ok = registeredType(node.Type); !ok {
+				// Reported when unmarshaling
+				continue
+			}
+		}
+
+		if includedNode := c.included[key]; includedNode != nil {
+			if !c.checked[key] {
+				c.checked[key] = true
+				c.checkNode(includedNode.Node, relatedType, includedNode.pointer)
+			}
+			continue
+		}
+
+		c.checkNode(node, relatedType, pointers[i])
+	}
+}
+
+// add records err if it is an *UnmarshalError for a member that has no
+// violation yet, reporting whether err is an *UnmarshalError.
+func (c *documentChecker) add(err error) bool {
+	unmarshalError, ok := err.(*UnmarshalError)
+	if !ok {
+		return false
+	}
+
+	for _, violation := range c.violations {
+		if violation.Pointer == unmarshalError.Pointer {
+			return true
+		}
+	}
+
+	c.violations = append(c.violations, unmarshalError)
+
+	return true
+}
+
+// result returns the violations found along with err, the error that stopped
+// unmarshaling, if any.  Errors that are not about a member of the payload,
+// e.g. one returned by a MetaUnmarshaler, are returned as is.
+func (c *documentChecker) result(err error) error {
+	if err != nil && !c.add(err) {
+		return err
+	}
+
+	if len(c.violations) == 0 {
+		return nil
+	}
+
+	return c.violations
+}
+
+func (c *documentChecker) violation(pointer string, t reflect.Type, value interface{}, err error) {
+	c.violations = append(c.violations, &UnmarshalError{
+		Pointer:  pointer,
+		Type:     t,
+		JSONKind: jsonKind(value),
+		Err:      err,
+	})
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
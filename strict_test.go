@@ -0,0 +1,170 @@
+package jsonapi
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func violationPointers(t *testing.T, err error) []string {
+	violations, ok := err.(UnmarshalErrors)
+	if !ok {
+		t.Fatalf("Was expecting UnmarshalErrors, got %v", err)
+	}
+
+	pointers := make([]string, len(violations))
+	for i, violation := range violations {
+		pointers[i] = violation.Pointer
+	}
+
+	return pointers
+}
+
+func TestStrictReportsEveryViolation(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": {
+			"type": "blogs",
+			"id": "1",
+			"attributes": {"title": "Strict", "subtitle": "unknown", "colour": "red"},
+			"relationships": {
+				"posts": {
+					"data": [
+						{"type": "posts", "id": "1"},
+						{"type": "posts", "id": "1"}
+					]
+				},
+				"authors": {"data": []},
+				"current_post": {"data": {"type": "comments", "id": "2"}}
+			}
+		},
+		"included": [
+			{"type": "posts", "id": "1", "attributes": {"title": "Hi"}},
+			{"type": "posts", "id": "1", "attributes": {"likes": 3}}
+		]
+	}`)
+
+	err := UnmarshalPayload(in, new(Blog), Strict())
+
+	expected := []string{
+		"/included/1",
+		"/data/attributes/colour",
+		"/data/attributes/subtitle",
+		"/data/relationships/authors",
+		"/data/relationships/current_post/data/type",
+		"/included/1/attributes/likes",
+		"/data/relationships/posts/data/1",
+	}
+	if a := violationPointers(t, err); !reflect.DeepEqual(expected, a) {
+		t.Fatalf("Was expecting violations at\n%s\ngot\n%s",
+			strings.Join(expected, "\n"), strings.Join(a, "\n"))
+	}
+
+	for i, sentinel := range []error{
+		ErrDuplicateResource, ErrUnknownAttribute, ErrUnknownAttribute,
+		ErrUnknownRelationship, ErrTypeConflict, ErrUnknownAttribute, ErrDuplicateResource,
+	} {
+		if violation := err.(UnmarshalErrors)[i]; !errors.Is(violation, sentinel) {
+			t.Fatalf("Was expecting %s to be %v, got %v", violation.Pointer, sentinel, violation.Err)
+		}
+	}
+}
+
+func TestUnmarshalErrorsIsAndAs(t *testing.T) {
+	in := strings.NewReader(`{"data": {"type": "blogs", "id": "1", "attributes": {"colour": "red"},
+		"relationships": {"current_post": {"data": {"type": "comments", "id": "1"}}}}}`)
+
+	err := UnmarshalPayload(in, new(Blog), Strict())
+	if len(err.(UnmarshalErrors)) != 2 {
+		t.Fatalf("Was expecting 2 violations, got %v", err)
+	}
+
+	// Matched through the Is and As methods, not only multi-error unwrapping
+	for _, sentinel := range []error{ErrUnknownAttribute, ErrTypeConflict} {
+		if !errors.Is(err, sentinel) {
+			t.Fatalf("Was expecting the errors to match %v", sentinel)
+		}
+	}
+	if errors.Is(err, ErrMissingID) {
+		t.Fatal("Was not expecting the errors to match ErrMissingID")
+	}
+
+	var unmarshalError *UnmarshalError
+	if !errors.As(err, &unmarshalError) || unmarshalError.Pointer != "/data/attributes/colour" {
+		t.Fatalf("Was expecting the first violation, got %v", unmarshalError)
+	}
+}
+
+func TestStrictTypeConflictIs409(t *testing.T) {
+	in := strings.NewReader(`{"data": {"type": "posts", "id": "1"}}`)
+
+	err := UnmarshalPayload(in, new(Blog), Strict())
+
+	errorObjects := err.(UnmarshalErrors).ErrorObjects()
+	if len(errorObjects) != 1 {
+		t.Fatalf("Was expecting a single error object, got %v", err)
+	}
+	if e, a := "409", errorObjects[0].Status; e != a {
+		t.Fatalf("Was expecting status %s, got %s", e, a)
+	}
+	if e, a := "/data/type", errorObjects[0].Source.Pointer; e != a {
+		t.Fatalf("Was expecting the pointer %s, got %s", e, a)
+	}
+}
+
+func TestRequireID(t *testing.T) {
+	in := strings.NewReader(`{"data": {"type": "blogs", "attributes": {"title": "New"}}}`)
+
+	err := UnmarshalPayload(in, new(Blog), RequireID())
+	if e, a := []string{"/data"}, violationPointers(t, err); !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting violations at %v, got %v", e, a)
+	}
+	if violation := err.(UnmarshalErrors)[0]; !errors.Is(violation, ErrMissingID) {
+		t.Fatalf("Was expecting ErrMissingID, got %v", violation.Err)
+	}
+
+	// Unknown members are only rejected in strict mode
+	in = strings.NewReader(`{"data": {"type": "blogs", "id": "1", "attributes": {"colour": "red"}}}`)
+	if err := UnmarshalPayload(in, new(Blog), RequireID()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStrictManyPayload(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": [
+			{"type": "posts", "id": "1", "attributes": {"title": 1}},
+			{"type": "posts", "id": "2", "attributes": {"colour": "red"}},
+			{"type": "posts", "id": "2"},
+			{"type": "posts"}
+		]
+	}`)
+
+	_, err := UnmarshalManyPayload(in, reflect.TypeOf(new(Post)), Strict(), RequireID())
+
+	expected := []string{
+		"/data/0/attributes/title",
+		"/data/1/attributes/colour",
+		"/data/2",
+		"/data/3",
+	}
+	if a := violationPointers(t, err); !reflect.DeepEqual(expected, a) {
+		t.Fatalf("Was expecting violations at %v, got %v", expected, a)
+	}
+}
+
+func TestStrictValidPayload(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": {
+			"type": "blogs",
+			"id": "1",
+			"attributes": {"title": "Strict"},
+			"relationships": {"posts": {"data": [{"type": "posts", "id": "1"}]}}
+		},
+		"included": [{"type": "posts", "id": "1", "attributes": {"title": "Hi"}}]
+	}`)
+
+	if err := UnmarshalPayload(in, new(Blog), Strict(), RequireID()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,296 @@
+package jsonapi
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TagError describes a problem with the jsonapi struct tags of a model.
+type TagError struct {
+	// Type is the model struct type.
+	Type reflect.Type
+	// Field is the name of the offending struct field, if the problem is
+	// with a single field.
+	Field string
+	// Err describes the problem.
+	Err error
+}
+
+// Error implements the `Error` interface.
+func (e *TagError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%v: %v", e.Type, e.Err)
+	}
+
+	return fmt.Sprintf("%v.%s: %v", e.Type, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *TagError) Unwrap() error {
+	return e.Err
+}
+
+// TagErrors is returned by Validate and Register, listing every problem found
+// with the jsonapi struct tags of the models.
+type TagErrors []*TagError
+
+// Error implements the `Error` interface.
+func (e TagErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Validate checks the jsonapi struct tags of model, which should be a pointer
+// to a struct, and of every model reachable through its relations.  It
+// ensures that each of them has:
+//
+//   - well formed tags with known annotations and options
+//   - exactly one primary field, of a supported id type
+//   - no two attributes or relationships sharing a name
+//   - relation fields of a pointer to struct or interface type, or a slice of
+//     either
+//
+// Every problem found is reported in the returned TagErrors.  Marshaling and
+// unmarshaling only fail on malformed tags, when they reach them, so
+// validating your models up front, e.g. with Register, surfaces all of these
+// at startup instead.
+func Validate(model interface{}) error {
+	var problems TagErrors
+
+	modelType := reflect.TypeOf(model)
+	if modelType != nil && modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+
+	if modelType == nil || modelType.Kind() != reflect.Struct {
+		problems = append(problems, &TagError{
+			Type: reflect.TypeOf(model),
+			Err:  errors.New("model should be a pointer to a struct"),
+		})
+		return problems
+	}
+
+	validateType(modelType, make(map[reflect.Type]bool), &problems)
+
+	if problems != nil {
+		return problems
+	}
+
+	return nil
+}
+
+func validateType(modelType reflect.Type, visited map[reflect.Type]bool,
+	problems *TagErrors) {
+	if visited[modelType] {
+		return
+	}
+	visited[modelType] = true
+
+	schema, _ := schemaOf(modelType)
+	*problems = append(*problems, schema.problems...)
+
+	problem := func(field *fieldSchema, err error) {
+		*problems = append(*problems, &TagError{
+			Type:  modelType,
+			Field: field.structField.Name,
+			Err:   err,
+		})
+	}
+
+	primaries := 0
+	members := make(map[string]string)
+
+	for _, field := range schema.fields {
+		switch field.annotation {
+		case annotationPrimary:
+			primaries++
+			if primaries > 1 {
+				problem(field, errors.New("more than one primary annotation"))
+			}
+			if field.name == "" {
+				problem(field, errors.New("primary annotation without a type"))
+			}
+			if !isIDType(field.structField.Type) {
+				problem(field, ErrBadJSONAPIID)
+			}
+		case annotationClientID:
+			if field.structField.Type.Kind() != reflect.String {
+				problem(field, errors.New("client-id should be a string"))
+			}
+		case annotationAttribute, annotationRelation:
+			if field.name == "" {
+				problem(field, fmt.Errorf("%s annotation without a name", field.annotation))
+			} else if other, ok := members[field.name]; ok {
+				problem(field, fmt.Errorf("name %q is already used by %s", field.name, other))
+			} else {
+				members[field.name] = field.structField.Name
+			}
+
+			if field.annotation != annotationRelation {
+				continue
+			}
+
+			if !isRelationType(field.structField.Type) {
+				problem(field, errors.New(
+					"relation should be a pointer to a struct, an interface, or a slice of either",
+				))
+				continue
+			}
+
+			// The records of polymorphic relationships are validated when
+			// their types are registered
+			if field.polymorphic() || field.identifiers() {
+				continue
+			}
+
+			validateType(field.relatedType(), visited, problems)
+		}
+	}
+
+	if primaries == 0 {
+		*problems = append(*problems, &TagError{
+			Type: modelType,
+			Err:  errors.New("missing primary annotation"),
+		})
+	}
+}
+
+// isIDType reports whether t, or the type it points to, is one of the types
+// supported for primary fields: strings, integers, and types that can both
+// format and parse their id, see IDMarshaler and IDUnmarshaler.
+func isIDType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	ptr := reflect.PtrTo(t)
+	marshals := ptr.Implements(idMarshalerType) ||
+		ptr.Implements(textMarshalerType) ||
+		ptr.Implements(stringerType)
+	unmarshals := ptr.Implements(idUnmarshalerType) ||
+		ptr.Implements(textUnmarshalerType)
+
+	return marshals && unmarshals
+}
+
+var (
+	idMarshalerType     = reflect.TypeOf((*IDMarshaler)(nil)).Elem()
+	idUnmarshalerType   = reflect.TypeOf((*IDUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	stringerType        = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// isRelationType reports whether t is a pointer to a struct, an interface,
+// or a slice of either.
+func isRelationType(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Interface ||
+		(t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct)
+}
+
+// registry maps the JSON API type of each registered model to its struct type.
+var registry = struct {
+	sync.RWMutex
+	types map[string]reflect.Type
+}{types: make(map[string]reflect.Type)}
+
+// Register validates the given models, see Validate, and records each of them
+// under its JSON API type, e.g. at startup,
+//
+//	func init() {
+//		jsonapi.MustRegister(new(Blog), new(Post), new(Comment))
+//	}
+//
+// Every problem found is reported in the returned TagErrors, including a JSON
+// API type that was already registered for a different struct type.
+// Registering the same model twice is allowed, and a struct value registers
+// its type like a pointer to it does.
+func Register(models ...interface{}) error {
+	var problems TagErrors
+
+	for _, model := range models {
+		if err := Validate(model); err != nil {
+			problems = append(problems, err.(TagErrors)...)
+			continue
+		}
+
+		modelType := reflect.TypeOf(model)
+		if modelType.Kind() == reflect.Ptr {
+			modelType = modelType.Elem()
+		}
+		schema, _ := schemaOf(modelType)
+
+		registry.Lock()
+		if registered, ok := registry.types[schema.typ]; ok && registered != modelType {
+			problems = append(problems, &TagError{
+				Type: modelType,
+				Err: fmt.Errorf(
+					"type %q is already registered for %v",
+					schema.typ,
+					registered,
+				),
+			})
+		} else {
+			registry.types[schema.typ] = modelType
+		}
+		registry.Unlock()
+	}
+
+	if problems != nil {
+		return problems
+	}
+
+	return nil
+}
+
+// registeredType returns the struct type registered for the given JSON API
+// type, if any.
+func registeredType(typ string) (reflect.Type, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	modelType, ok := registry.types[typ]
+	return modelType, ok
+}
+
+// registeredImplementations returns the registered struct types whose
+// pointers implement the given interface type.
+func registeredImplementations(iface reflect.Type) []reflect.Type {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	var implementations []reflect.Type
+	for _, modelType := range registry.types {
+		if reflect.PtrTo(modelType).Implements(iface) {
+			implementations = append(implementations, modelType)
+		}
+	}
+
+	return implementations
+}
+
+// MustRegister is like Register but panics if any of the models is invalid.
+func MustRegister(models ...interface{}) {
+	if err := Register(models...); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,128 @@
+package jsonapi
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type InvalidAuthor struct {
+	ID    float64  `jsonapi:"primary,invalid-authors"`
+	Name  string   `jsonapi:"attr,name,omitempty,uppercase"`
+	Alias string   `jsonapi:"attr,name"`
+	Books []Book   `jsonapi:"relation,books"`
+	Best  *Comment `jsonapi:"relation,name"`
+}
+
+type InvalidPublisher struct {
+	ID      string           `jsonapi:"primary,invalid-publishers"`
+	Authors []*InvalidAuthor `jsonapi:"relation,authors"`
+	Country string           `jsonapi:"country,name"`
+}
+
+type NoPrimary struct {
+	Name string `jsonapi:"attr,name"`
+}
+
+func TestValidateValidModels(t *testing.T) {
+	for _, model := range []interface{}{
+		new(Blog), new(Post), new(Comment), new(Book), new(Timestamp), new(Car),
+		new(WithPointer), new(MetaPost), new(OrderLine), new(SkuProduct),
+		new(Message), new(Playlist),
+	} {
+		if err := Validate(model); err != nil {
+			t.Fatalf("Was expecting %T to be valid, got %v", model, err)
+		}
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	err := Validate(new(InvalidPublisher))
+
+	problems, ok := err.(TagErrors)
+	if !ok {
+		t.Fatalf("Was expecting TagErrors, got %v", err)
+	}
+
+	expected := []string{
+		"jsonapi.InvalidPublisher.Country: Unsupported jsonapi tag annotation, country",
+		"jsonapi.InvalidAuthor.Name: unknown attr option \"uppercase\"",
+		"jsonapi.InvalidAuthor.ID: " + ErrBadJSONAPIID.Error(),
+		"jsonapi.InvalidAuthor.Alias: name \"name\" is already used by Name",
+		"jsonapi.InvalidAuthor.Books: relation should be a pointer to a struct, an interface, or a slice of either",
+		"jsonapi.InvalidAuthor.Best: name \"name\" is already used by Name",
+	}
+	actual := make([]string, len(problems))
+	for i, problem := range problems {
+		actual[i] = problem.Error()
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Was expecting\n%s\ngot\n%s",
+			strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+
+	if !errors.Is(problems[2], ErrBadJSONAPIID) {
+		t.Fatalf("Was expecting the underlying error to be ErrBadJSONAPIID")
+	}
+}
+
+func TestValidateMissingPrimary(t *testing.T) {
+	for model, count := range map[interface{}]int{
+		new(NoPrimary): 1,
+		new(BadModel):  2,
+		"blogs":        1,
+	} {
+		err := Validate(model)
+		if problems, ok := err.(TagErrors); !ok || len(problems) != count {
+			t.Fatalf("Was expecting %d problems for %T, got %v", count, model, err)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	if err := Register(new(Blog), new(Post), new(Blog)); err != nil {
+		t.Fatal(err)
+	}
+
+	type OtherBlog struct {
+		ID int `jsonapi:"primary,blogs"`
+	}
+
+	err := Register(new(OtherBlog))
+	problems, ok := err.(TagErrors)
+	if !ok || len(problems) != 1 || !strings.Contains(err.Error(), "already registered") {
+		t.Fatalf("Was expecting the blogs type to be taken, got %v", err)
+	}
+
+	registry.RLock()
+	registered := registry.types["blogs"]
+	registry.RUnlock()
+	if registered != reflect.TypeOf(Blog{}) {
+		t.Fatalf("Was expecting blogs to stay registered to Blog, got %v", registered)
+	}
+}
+
+func TestRegisterStructValue(t *testing.T) {
+	if err := Register(Blog{}, Comment{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if modelType, ok := registeredType("comments"); !ok || modelType != reflect.TypeOf(Comment{}) {
+		t.Fatalf("Was expecting comments to be registered to Comment, got %v", modelType)
+	}
+
+	if err := Register(1); err == nil {
+		t.Fatal("Was expecting a non-struct model to be rejected")
+	}
+}
+
+func TestMustRegisterPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Was expecting MustRegister to panic")
+		}
+	}()
+
+	MustRegister(new(InvalidPublisher))
+}